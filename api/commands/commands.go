@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var registry = map[string]command{}
+
+func Run(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	cmd, ok := registry[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\navailable commands:\n", args[0])
+		for name, c := range registry {
+			fmt.Fprintf(os.Stderr, "  %s %s\n", name, c.usage)
+		}
+		os.Exit(2)
+	}
+
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+
+	return true
+}
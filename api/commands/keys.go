@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"api/utils"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func init() {
+	registry["keys"] = command{
+		usage: "generate|rotate|purge [-alg RS256|EdDSA] [-max-age 720h]",
+		run:   keys,
+	}
+}
+
+func keys(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected a subcommand: generate, rotate or purge")
+	}
+
+	fs := flag.NewFlagSet("keys "+args[0], flag.ContinueOnError)
+	alg := fs.String("alg", utils.JwtAlgorithm(), "signing algorithm (RS256 or EdDSA)")
+	maxAge := fs.Duration("max-age", 0, "rotate only when the active key is older than this")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *alg == "HS256" {
+		*alg = "RS256"
+	}
+
+	if utils.Security().KeyEncryptionKey == "" {
+		return errors.New("security.key_encryption_key is required to manage signing keys")
+	}
+
+	utils.InitDB()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "generate":
+		key, err := utils.GenerateSigningKey(ctx, *alg)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("generated %s signing key %s\n", key.Algorithm, key.Kid)
+
+	case "rotate":
+		age := *maxAge
+		if age == 0 {
+			age = time.Nanosecond
+		}
+
+		rotated, err := utils.RotateSigningKeys(ctx, *alg, age)
+		if err != nil {
+			return err
+		}
+
+		if rotated {
+			fmt.Println("signing key rotated")
+		} else {
+			fmt.Println("active signing key is still within its max age; nothing to do")
+		}
+
+	case "purge":
+		if err := utils.PurgeSigningKeys(ctx); err != nil {
+			return err
+		}
+
+		fmt.Println("expired signing keys purged")
+
+	default:
+		return fmt.Errorf("unknown subcommand %q", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"api/models"
+	"api/router"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	registry["permissions"] = command{
+		usage: "sync",
+		run:   permissions,
+	}
+}
+
+func permissions(args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return errors.New("expected a subcommand: sync")
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router.InitRouters(gin.New())
+
+	res, err := models.Permission{}.Sync(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, name := range res.Created {
+		fmt.Printf("created %s\n", name)
+	}
+
+	for _, name := range res.Orphaned {
+		fmt.Printf("orphaned %s\n", name)
+	}
+
+	fmt.Printf("%d permission(s) created, %d flagged as orphaned\n", len(res.Created), len(res.Orphaned))
+	return nil
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"api/models"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AmenityController struct {
+	AppController
+	m models.Amenity
+}
+
+func (c AmenityController) InitAmenityController(router *gin.Engine) {
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/amenity", apiVersion)))
+
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("amenity", "manage", "edit"), c.Upsert)
+	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("amenity", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("amenity", "manage", "update_status"), c.UpdateStatus)
+}
+
+func (c AmenityController) Upsert(ctx *gin.Context) {
+	var form *models.Amenity
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	httpStatus, res, err := c.m.Upsert(ctx, *form)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res})
+}
+
+func (c AmenityController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	data := gin.H{"total": res.Count, "data": res.Items}
+
+	if ctx.Param("uuid") != "all" {
+		data = gin.H{"data": res.Item}
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (c AmenityController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
+
+func (c AmenityController) UpdateStatus(ctx *gin.Context) {
+	status, msg, err := c.m.UpdateStatus(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"api/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	AppController
+	m models.APIKey
+}
+
+func (c APIKeyController) InitAPIKeyController(router *gin.Engine) {
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/api_key", apiVersion)))
+
+	r.POST("", c.mw.Authenticate, c.Create)
+	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
+}
+
+func (c APIKeyController) Create(ctx *gin.Context) {
+	var form *models.APIKey
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if form.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Name is required."})
+		return
+	}
+
+	httpStatus, res, err := c.m.Create(ctx, *form)
+
+	if errors.Is(err, models.ErrAPIKeyScope) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, models.ErrAPIKeyPermission) || errors.Is(err, models.ErrAPIKeyNested) || errors.Is(err, models.ErrAPIKeyOrganization) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res, "message": "Store this key now; it will not be shown again."})
+}
+
+func (c APIKeyController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	data := gin.H{"total": res.Count, "data": res.Items}
+
+	if ctx.Param("uuid") != "all" {
+		data = gin.H{"data": res.Item}
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (c APIKeyController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
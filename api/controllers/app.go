@@ -4,10 +4,12 @@ import (
 	"api/middleware"
 	"api/models"
 	"api/utils"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun/driver/pgdriver"
@@ -40,21 +42,41 @@ func (c *AppController) sanitizeCtx(ctx *gin.Context) models.QueryParams {
 	}
 
 	isDeleted, _ := strconv.ParseBool(ctx.Query("deleted"))
+	radiusKm, _ := strconv.ParseFloat(ctx.Query("radius_km"), 64)
 
 	return models.QueryParams{
-		UUID:        ctx.Param("uuid"),
-		Sort:        ctx.Query("sort"),
-		Status:      ctx.Query("status"),
-		Filter:      ctx.Query("filter"),
-		FilterExtOp: filterExtOp,
-		FilterExt:   filterExt,
-		Deleted:     isDeleted,
-		Limit:       limit,
-		Page:        page,
-		Ctx:         ctx,
+		UUID:         ctx.Param("uuid"),
+		Sort:         ctx.Query("sort"),
+		Status:       ctx.Query("status"),
+		Filter:       ctx.Query("filter"),
+		FilterExtOp:  filterExtOp,
+		FilterExt:    filterExt,
+		Deleted:      isDeleted,
+		Validity:     ctx.Query("validity"),
+		Organization: ctx.Query("organization"),
+		Category:     ctx.Query("category"),
+		Amenities:    c.splitList(ctx.Query("amenities")),
+		Attributes:   ctx.QueryMap("attr"),
+		Near:         ctx.Query("near"),
+		RadiusKm:     radiusKm,
+		BBox:         ctx.Query("bbox"),
+		Facets:       c.splitList(ctx.Query("facets")),
+		Limit:        limit,
+		Page:         page,
+		Ctx:          ctx,
 	}
 }
 
+func (c *AppController) splitList(value string) (items []string) {
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
 func (c *AppController) cleanErr(err error) string {
 	if pgErr, ok := err.(pgdriver.Error); ok {
 		return fmt.Sprintf("Postgres Error: %s", pgErr.Field('M'))
@@ -64,5 +86,10 @@ func (c *AppController) cleanErr(err error) string {
 }
 
 func (c *AppController) handleError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, models.ErrInvalidFilter) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx.JSON(http.StatusNotFound, gin.H{"error": message, "details": err.Error()})
 }
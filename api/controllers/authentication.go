@@ -3,26 +3,161 @@ package controllers
 import (
 	"api/models"
 	"api/utils"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthenticationController struct {
 	AppController
-	u models.Authentication
+	u  models.Authentication
+	rt models.RefreshToken
+	s  models.Session
+	pr models.PasswordReset
+	tf models.TwoFactor
+	ui models.UserIdentity
+	la models.LoginAttempt
 }
 
 func (c AuthenticationController) InitUserController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/auth", apiVersion))
+	router.GET("/.well-known/jwks.json", c.JWKS)
 
-	r.POST("/login", c.Login)
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/auth", apiVersion)))
+
+	r.POST("/register", c.Register)
+	r.GET("/verify", c.VerifyEmail)
+	r.POST("/login", c.mw.LoginRateLimit, c.Login)
+	r.POST("/login/2fa", c.mw.LoginRateLimit, c.LoginTwoFactor)
+	r.GET("/oidc/:provider", c.OIDCLogin)
+	r.GET("/oidc/:provider/callback", c.OIDCCallback)
+	r.GET("/oidc/:provider/link", c.mw.Authenticate, c.mw.DenyImpersonation, c.OIDCLink)
+	r.POST("/password/forgot", c.mw.LoginRateLimit, c.ForgotPassword)
+	r.POST("/password/reset", c.ResetPassword)
+	r.POST("/refresh", c.Refresh)
+	r.POST("/logout", c.mw.Authenticate, c.Logout)
+	r.GET("/sessions", c.mw.Authenticate, c.Sessions)
+	r.DELETE("/sessions/:session", c.mw.Authenticate, c.RevokeSession)
+	r.POST("/2fa/enroll", c.mw.AuthenticateEnrollment, c.mw.DenyImpersonation, c.EnrollTwoFactor)
+	r.POST("/2fa/confirm", c.mw.AuthenticateEnrollment, c.mw.DenyImpersonation, c.ConfirmTwoFactor)
+	r.POST("/2fa/recovery-codes", c.mw.Authenticate, c.mw.DenyImpersonation, c.RegenerateRecoveryCodes)
+	r.POST("/2fa/disable", c.mw.Authenticate, c.mw.DenyImpersonation, c.DisableTwoFactor)
+}
+
+func (c AuthenticationController) Register(ctx *gin.Context) {
+	var form struct {
+		Username  string  `json:"username" binding:"required"`
+		Email     string  `json:"email" binding:"required,email"`
+		Password  string  `json:"password" binding:"required"`
+		FirstName *string `json:"first_name"`
+		LastName  *string `json:"last_name"`
+		Mobile    *string `json:"mobile"`
+		Optin     bool    `json:"optin"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if len(form.Password) < minPasswordLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters.", minPasswordLength)})
+		return
+	}
+
+	item := models.User{
+		Username:  form.Username,
+		Email:     form.Email,
+		FirstName: form.FirstName,
+		LastName:  form.LastName,
+		Mobile:    form.Mobile,
+		Optin:     form.Optin,
+	}
+
+	verifyURL := fmt.Sprintf("%s/%s/auth/verify", utils.BaseURL(), strings.Trim(apiVersion, "/"))
+	u, err := c.u.Register(ctx, item, form.Password, verifyURL)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"success": "Registration successful. Please check your email to verify your account.", "data": u})
+}
+
+func (c AuthenticationController) VerifyEmail(ctx *gin.Context) {
+	err := c.u.VerifyEmail(ctx, ctx.Query("token"))
+
+	if errors.Is(err, models.ErrInvalidToken) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "Email verified successfully."})
+}
+
+func (c AuthenticationController) ForgotPassword(ctx *gin.Context) {
+	var form struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	c.pr.Request(ctx, form.Email, utils.FrontendURL()+"/reset-password")
+
+	ctx.JSON(http.StatusAccepted, gin.H{"success": "If an account exists for that email, a password reset link has been sent."})
+}
+
+func (c AuthenticationController) ResetPassword(ctx *gin.Context) {
+	var form struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if len(form.Password) < minPasswordLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters.", minPasswordLength)})
+		return
+	}
+
+	err := c.pr.Reset(ctx, form.Token, form.Password)
+
+	if errors.Is(err, models.ErrInvalidToken) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired password reset token."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	utils.ClearCookie(ctx)
+	ctx.JSON(http.StatusOK, gin.H{"success": "Password reset successfully. Please log in again."})
 }
 
 func (c AuthenticationController) Login(ctx *gin.Context) {
 	var form struct {
-		Username string `json:"username"`
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+		Device   string `json:"device"`
 	}
 
 	if err := ctx.ShouldBindJSON(&form); err != nil {
@@ -30,13 +165,388 @@ func (c AuthenticationController) Login(ctx *gin.Context) {
 		return
 	}
 
-	if u, err := c.u.Login(ctx, form.Username); err == nil {
-		if jwt, err := utils.GenerateJWT(u.UUID, u.Username); err == nil {
-			utils.SetCooke(ctx, jwt)
-			ctx.JSON(http.StatusOK, gin.H{"success": "Login successfully.", "data": u})
+	u, err := c.u.Login(ctx, form.Username, form.Password)
+	if c.loginError(ctx, err) {
+		return
+	}
+
+	c.completeLogin(ctx, u, form.Device)
+}
+
+func (c AuthenticationController) OIDCLogin(ctx *gin.Context) {
+	c.startOIDC(ctx, "")
+}
+
+func (c AuthenticationController) OIDCLink(ctx *gin.Context) {
+	c.startOIDC(ctx, ctx.GetString("userUUID"))
+}
+
+func (c AuthenticationController) startOIDC(ctx *gin.Context, linkUser string) {
+	provider, err := utils.OIDCProvider(ctx.Param("provider"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider."})
+		return
+	}
+
+	state, _ := utils.RandomToken(16)
+	nonce, _ := utils.RandomToken(16)
+	verifier, _ := utils.RandomToken(32)
+
+	cookie, err := utils.GenerateOIDCState(provider.Name, state, nonce, verifier, linkUser)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate token."})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable.", "details": err.Error()})
+		return
+	}
+
+	ctx.SetCookie("oidc_state", cookie, 600, "/", "localhost", false, true)
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+func (c AuthenticationController) OIDCCallback(ctx *gin.Context) {
+	provider, err := utils.OIDCProvider(ctx.Param("provider"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider."})
+		return
+	}
+
+	if e := ctx.Query("error"); e != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider login failed.", "details": e + " " + ctx.Query("error_description")})
+		return
+	}
+
+	cookie, _ := ctx.Cookie("oidc_state")
+	ctx.SetCookie("oidc_state", "", -1, "/", "localhost", false, true)
+
+	state, err := utils.VerifyOIDCState(cookie)
+	if err != nil || state.Provider != provider.Name || state.State != ctx.Query("state") {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state."})
+		return
+	}
+
+	idToken, err := provider.Exchange(ctx, ctx.Query("code"), state.Verifier)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider login failed.", "details": err.Error()})
+		return
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, idToken, state.Nonce)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider login failed.", "details": err.Error()})
+		return
+	}
+
+	if state.LinkUser != "" {
+		identity, err := c.ui.Link(ctx, provider.Name, claims, state.LinkUser)
+
+		if errors.Is(err, models.ErrIdentityLinked) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err != nil {
+			c.handleError(ctx, err, c.cleanErr(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"data": identity})
+		return
+	}
+
+	u, err := c.ui.Resolve(ctx, provider.Name, claims, provider.Config.AllowSignup)
+
+	switch {
+	case errors.Is(err, models.ErrIdentityLinkRequired):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists. Log in and link this identity from your account."})
+		return
+	case errors.Is(err, models.ErrIdentityEmailNotVerified):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your identity provider has not verified your email address."})
+		return
+	case errors.Is(err, models.ErrIdentitySignupDisabled):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "No account is linked to this identity."})
+		return
+	case err != nil:
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	u, err = c.u.LoginIdentity(ctx, u)
+	if c.loginError(ctx, err) {
+		return
+	}
+
+	c.completeLogin(ctx, u, "")
+}
+
+func (c AuthenticationController) completeLogin(ctx *gin.Context, u models.User, device string) {
+	if !u.TOTPEnabledAt.IsZero() {
+		challenge, err := utils.GenerateActionToken(u.UUID, utils.TwoFactorChallengeToken, 5*time.Minute)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate token."})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"success": "Two-factor code required.", "two_factor_required": true, "challenge_token": challenge})
+		return
+	}
+
+	if c.tf.Required(ctx, u.ID) {
+		enrollment, err := utils.GenerateActionToken(u.UUID, utils.TwoFactorEnrollToken, 15*time.Minute)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate token."})
 			return
 		}
+
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your role; please enroll to continue.", "two_factor_setup_required": true, "enrollment_token": enrollment})
+		return
+	}
+
+	c.issueSession(ctx, u, device)
+}
+
+func (c AuthenticationController) LoginTwoFactor(ctx *gin.Context) {
+	var form struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+		Device         string `json:"device"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	claims, err := utils.VerifyJWT(form.ChallengeToken, utils.TwoFactorChallengeToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge token."})
+		return
+	}
+
+	u, err := c.u.LoginTwoFactor(ctx, claims.UUID, form.Code)
+	if c.loginError(ctx, err) {
+		return
+	}
+
+	c.issueSession(ctx, u, form.Device)
+}
+
+func (c AuthenticationController) issueSession(ctx *gin.Context, u models.User, device string) {
+	jwt, err := c.rt.Issue(ctx, u, device)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate token."})
+		return
+	}
+
+	c.la.RecordSuccess(ctx, u)
+
+	utils.SetCooke(ctx, jwt)
+	ctx.JSON(http.StatusOK, gin.H{"success": "Login successfully.", "data": u, "tokens": jwt})
+}
+
+func (c AuthenticationController) loginError(ctx *gin.Context, err error) bool {
+	var retryAfter int
+	if lockout := (*models.LockoutError)(nil); errors.As(err, &lockout) {
+		retryAfter = int(math.Ceil(lockout.RetryAfter.Seconds()))
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+	}
+
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, models.ErrAccountLocked):
+		ctx.JSON(http.StatusLocked, gin.H{"error": "Your account is temporarily locked due to too many failed login attempts; please try again later.", "retry_after": retryAfter})
+	case errors.Is(err, models.ErrLoginThrottled), errors.Is(err, models.ErrTooManyAttempts):
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts; please wait before retrying.", "retry_after": retryAfter})
+	case errors.Is(err, models.ErrInvalidCredentials):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password."})
+	case errors.Is(err, models.ErrTwoFactorInvalidCode), errors.Is(err, models.ErrTwoFactorNotEnrolled), errors.Is(err, models.ErrInvalidToken):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code."})
+	case errors.Is(err, models.ErrAccountDeleted):
+		ctx.JSON(http.StatusGone, gin.H{"error": "Your account has been deleted; please contact support for more information or assistance."})
+	case errors.Is(err, models.ErrAccountArchived):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your account has been archived; please contact support for more information or assistance."})
+	case errors.Is(err, models.ErrEmailNotVerified):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address before logging in."})
+	default:
+		c.handleError(ctx, err, c.cleanErr(err))
+	}
+
+	return true
+}
+
+func (c AuthenticationController) Refresh(ctx *gin.Context) {
+	var form struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	ctx.ShouldBindJSON(&form)
+
+	if form.RefreshToken == "" {
+		form.RefreshToken, _ = ctx.Cookie("refresh_token")
+	}
+
+	jwt, err := c.rt.Rotate(ctx, form.RefreshToken)
+
+	switch {
+	case errors.Is(err, models.ErrRefreshTokenReused):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has already been used; all sessions for this login have been revoked."})
+		return
+	case errors.Is(err, models.ErrRefreshTokenInvalid):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token."})
+		return
+	case err != nil:
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	utils.SetCooke(ctx, jwt)
+	ctx.JSON(http.StatusOK, gin.H{"success": "Token refreshed successfully.", "data": jwt})
+}
+
+func (c AuthenticationController) Logout(ctx *gin.Context) {
+	err := c.s.Revoke(ctx, ctx.GetString("sessionId"), ctx.GetString("userUUID"))
+
+	utils.ClearCookie(ctx)
+
+	if err != nil && !errors.Is(err, models.ErrSessionRevoked) {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "Logout successfully."})
+}
+
+func (c AuthenticationController) Sessions(ctx *gin.Context) {
+	res, err := c.s.Read(c.sanitizeCtx(ctx), ctx.GetString("userUUID"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"total": res.Count, "data": res.Items})
+}
+
+func (c AuthenticationController) RevokeSession(ctx *gin.Context) {
+	err := c.s.Revoke(ctx, ctx.Param("session"), ctx.GetString("userUUID"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if ctx.Param("session") == ctx.GetString("sessionId") {
+		utils.ClearCookie(ctx)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+func (c AuthenticationController) EnrollTwoFactor(ctx *gin.Context) {
+	secret, uri, err := c.tf.Enroll(ctx, int64(ctx.GetInt("userId")))
+
+	if errors.Is(err, models.ErrTwoFactorAlreadyEnabled) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"secret": secret, "otpauth_uri": uri}})
+}
+
+func (c AuthenticationController) ConfirmTwoFactor(ctx *gin.Context) {
+	var form struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	codes, err := c.tf.Confirm(ctx, int64(ctx.GetInt("userId")), form.Code)
+	if c.twoFactorError(ctx, err) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "Two-factor authentication enabled.", "data": gin.H{"recovery_codes": codes}})
+}
+
+func (c AuthenticationController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var form struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	codes, err := c.tf.RegenerateRecoveryCodes(ctx, int64(ctx.GetInt("userId")), form.Code)
+	if c.twoFactorError(ctx, err) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"recovery_codes": codes}})
+}
+
+func (c AuthenticationController) DisableTwoFactor(ctx *gin.Context) {
+	var form struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if c.tf.Required(ctx, int64(ctx.GetInt("userId"))) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your role and cannot be disabled."})
+		return
+	}
+
+	err := c.tf.Disable(ctx, int64(ctx.GetInt("userId")), form.Code)
+	if c.twoFactorError(ctx, err) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "Two-factor authentication disabled."})
+}
+
+func (c AuthenticationController) twoFactorError(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, models.ErrTwoFactorInvalidCode):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor code."})
+	case errors.Is(err, models.ErrTwoFactorNotEnrolled):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication has not been enrolled."})
+	case errors.Is(err, models.ErrTwoFactorAlreadyEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled."})
+	default:
+		c.handleError(ctx, err, c.cleanErr(err))
+	}
+
+	return true
+}
+
+func (c AuthenticationController) JWKS(ctx *gin.Context) {
+	set, err := utils.JWKS(ctx)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
 	}
 
-	ctx.JSON(http.StatusNotFound, gin.H{"error": "Username does not exist."})
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, set)
 }
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"api/models"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryController struct {
+	AppController
+	m models.Category
+}
+
+func (c CategoryController) InitCategoryController(router *gin.Engine) {
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/category", apiVersion)))
+
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("category", "manage", "edit"), c.Upsert)
+	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("category", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("category", "manage", "update_status"), c.UpdateStatus)
+}
+
+func (c CategoryController) Upsert(ctx *gin.Context) {
+	var form *models.Category
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	httpStatus, res, err := c.m.Upsert(ctx, *form)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res})
+}
+
+func (c CategoryController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	data := gin.H{"total": res.Count, "data": res.Items}
+
+	if ctx.Param("uuid") != "all" {
+		data = gin.H{"data": res.Item}
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (c CategoryController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
+
+func (c CategoryController) UpdateStatus(ctx *gin.Context) {
+	status, msg, err := c.m.UpdateStatus(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
+}
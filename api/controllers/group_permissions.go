@@ -14,12 +14,12 @@ type GroupPermissionController struct {
 }
 
 func (c GroupPermissionController) InitGroupPermissionController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/group_permission", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/group_permission", apiVersion)))
 
-	r.POST("", c.mw.Authenticate, c.Upsert)
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("group_permission", "manage", "edit"), c.Upsert)
 	r.GET("/:uuid", c.mw.Authenticate, c.Read)
-	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
-	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("group_permission", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("group_permission", "manage", "update_status"), c.UpdateStatus)
 }
 
 func (c GroupPermissionController) Upsert(ctx *gin.Context) {
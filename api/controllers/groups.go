@@ -14,12 +14,12 @@ type GroupController struct {
 }
 
 func (c GroupController) InitGroupController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/group", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/group", apiVersion)))
 
-	r.POST("", c.mw.Authenticate, c.Upsert)
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("group", "manage", "edit"), c.Upsert)
 	r.GET("/:uuid", c.mw.Authenticate, c.Read)
-	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
-	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("group", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("group", "manage", "update_status"), c.UpdateStatus)
 }
 
 func (c GroupController) Upsert(ctx *gin.Context) {
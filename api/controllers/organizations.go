@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"api/models"
+	"api/utils"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationController struct {
+	AppController
+	m  models.Organization
+	om models.OrganizationMember
+}
+
+func (c OrganizationController) InitOrganizationController(router *gin.Engine) {
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/organization", apiVersion)))
+
+	r.POST("", c.mw.Authenticate, c.mw.RequireVerified, c.Upsert)
+	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckOrganizationPermission("uuid", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckOrganizationPermission("uuid", "update_status"), c.UpdateStatus)
+
+	r.POST("/:uuid/members", c.mw.Authenticate, c.mw.CheckOrganizationPermission("uuid", "manage"), c.Invite)
+	r.GET("/:uuid/members", c.mw.Authenticate, c.mw.CheckOrganizationPermission("uuid", "read"), c.Members)
+	r.DELETE("/:uuid/members/:member", c.mw.Authenticate, c.mw.CheckOrganizationPermission("uuid", "manage"), c.RemoveMember)
+
+	r.POST("/invitations/accept", c.mw.Authenticate, c.mw.RequireVerified, c.Accept)
+}
+
+func (c OrganizationController) Upsert(ctx *gin.Context) {
+	var form *models.Organization
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	httpStatus, res, err := c.m.Upsert(ctx, *form)
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(httpStatus, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res})
+}
+
+func (c OrganizationController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	data := gin.H{"total": res.Count, "data": res.Items}
+
+	if ctx.Param("uuid") != "all" {
+		data = gin.H{"data": res.Item}
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (c OrganizationController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
+
+func (c OrganizationController) UpdateStatus(ctx *gin.Context) {
+	status, msg, err := c.m.UpdateStatus(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
+}
+
+func (c OrganizationController) Invite(ctx *gin.Context) {
+	var form struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A valid email and role are required."})
+		return
+	}
+
+	httpStatus, res, err := c.om.Invite(ctx, ctx.Param("uuid"), form.Email, form.Role, utils.FrontendURL()+"/invitations/accept")
+
+	if errors.Is(err, models.ErrOrganizationRole) {
+		ctx.JSON(httpStatus, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res})
+}
+
+func (c OrganizationController) Members(ctx *gin.Context) {
+	res, err := c.om.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"total": res.Count, "data": res.Items})
+}
+
+func (c OrganizationController) RemoveMember(ctx *gin.Context) {
+	deletedAt, err := c.om.Remove(ctx, ctx.Param("uuid"), ctx.Param("member"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": "removed successfully"})
+}
+
+func (c OrganizationController) Accept(ctx *gin.Context) {
+	var form struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Token is required."})
+		return
+	}
+
+	res, err := c.om.Accept(ctx, form.Token)
+
+	if errors.Is(err, models.ErrInvalidToken) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, models.ErrInviteEmail) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": res})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"api/models"
+	"api/utils"
 	"fmt"
 	"net/http"
 
@@ -14,12 +15,15 @@ type PermissionController struct {
 }
 
 func (c PermissionController) InitPermissionController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/permission", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/permission", apiVersion)))
 
-	r.POST("", c.mw.Authenticate, c.Upsert)
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("permission", "manage", "edit"), c.Upsert)
+	r.GET("/catalog", c.mw.Authenticate, c.mw.CheckPermission("permission", "manage", "read"), c.Catalog)
+	r.POST("/sync", c.mw.Authenticate, c.mw.CheckPermission("permission", "manage"), c.Sync)
+	r.GET("/cache/stats", c.mw.Authenticate, c.mw.CheckPermission("permission", "manage"), c.CacheStats)
 	r.GET("/:uuid", c.mw.Authenticate, c.Read)
-	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
-	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("permission", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("permission", "manage", "update_status"), c.UpdateStatus)
 }
 
 func (c PermissionController) Upsert(ctx *gin.Context) {
@@ -77,3 +81,29 @@ func (c PermissionController) UpdateStatus(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
 }
+
+func (c PermissionController) CacheStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": utils.PermissionStats()})
+}
+
+func (c PermissionController) Catalog(ctx *gin.Context) {
+	res, err := c.m.Catalog(ctx)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+func (c PermissionController) Sync(ctx *gin.Context) {
+	res, err := c.m.Sync(ctx)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": res})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"api/models"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -14,12 +15,12 @@ type RoleController struct {
 }
 
 func (c RoleController) InitRoleController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/role", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/role", apiVersion)))
 
-	r.POST("", c.mw.Authenticate, c.Upsert)
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("role", "manage", "edit"), c.Upsert)
 	r.GET("/:uuid", c.mw.Authenticate, c.Read)
-	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
-	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("role", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("role", "manage", "update_status"), c.UpdateStatus)
 }
 
 func (c RoleController) Upsert(ctx *gin.Context) {
@@ -31,6 +32,11 @@ func (c RoleController) Upsert(ctx *gin.Context) {
 
 	httpStatus, res, err := c.m.Upsert(ctx, *form)
 
+	if errors.Is(err, models.ErrRoleCycle) {
+		ctx.JSON(httpStatus, gin.H{"error": "A role cannot inherit from itself or one of its descendants."})
+		return
+	}
+
 	if err != nil {
 		c.handleError(ctx, err, c.cleanErr(err))
 		return
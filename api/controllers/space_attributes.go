@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"api/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceAttributeController struct {
+	AppController
+	m models.SpaceAttribute
+}
+
+func (c SpaceAttributeController) InitSpaceAttributeController(router *gin.Engine) {
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/space_attribute", apiVersion)))
+
+	r.POST("", c.mw.Authenticate, c.mw.RequireVerified, c.Upsert)
+	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+}
+
+func (c SpaceAttributeController) Upsert(ctx *gin.Context) {
+	var form *models.SpaceAttribute
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	httpStatus, res, err := c.m.Upsert(ctx, *form)
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(httpStatus, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if errors.Is(err, models.ErrAttributeCategory) || errors.Is(err, models.ErrAttributeValue) {
+		ctx.JSON(httpStatus, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res})
+}
+
+func (c SpaceAttributeController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	data := gin.H{"total": res.Count, "data": res.Items}
+
+	if ctx.Param("uuid") != "all" {
+		data = gin.H{"data": res.Item}
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (c SpaceAttributeController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"))
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
+
+func (c SpaceAttributeController) UpdateStatus(ctx *gin.Context) {
+	status, msg, err := c.m.UpdateStatus(ctx, ctx.Param("uuid"))
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"api/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceCategoryController struct {
+	AppController
+	m models.SpaceCategory
+}
+
+func (c SpaceCategoryController) InitSpaceCategoryController(router *gin.Engine) {
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/space_category", apiVersion)))
+
+	r.POST("", c.mw.Authenticate, c.mw.RequireVerified, c.Upsert)
+	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+}
+
+func (c SpaceCategoryController) Upsert(ctx *gin.Context) {
+	var form *models.SpaceCategory
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	httpStatus, res, err := c.m.Upsert(ctx, *form)
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(httpStatus, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(httpStatus, gin.H{"data": res})
+}
+
+func (c SpaceCategoryController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	data := gin.H{"total": res.Count, "data": res.Items}
+
+	if ctx.Param("uuid") != "all" {
+		data = gin.H{"data": res.Item}
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (c SpaceCategoryController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"))
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
+
+func (c SpaceCategoryController) UpdateStatus(ctx *gin.Context) {
+	status, msg, err := c.m.UpdateStatus(ctx, ctx.Param("uuid"))
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
+}
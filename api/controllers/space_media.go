@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"api/models"
+	"api/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SpaceMediaController struct {
+	AppController
+	m models.SpaceMedia
+}
+
+func (c SpaceMediaController) InitSpaceMediaController(router *gin.Engine) {
+	if _, ok := utils.Store().(*utils.LocalStorage); ok {
+		router.GET("/media/*key", c.mw.AuthenticateOptional, c.Serve)
+	}
+
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/space", apiVersion)))
+
+	r.POST("/:uuid/media", c.mw.Authenticate, c.mw.RequireVerified, c.mw.Authorize("space", "edit"), c.Upload)
+	r.GET("/:uuid/media", c.mw.Authenticate, c.mw.Authorize("space", "read"), c.Read)
+	r.PUT("/:uuid/media/order", c.mw.Authenticate, c.mw.Authorize("space", "edit"), c.Reorder)
+	r.PATCH("/:uuid/media/:media/cover", c.mw.Authenticate, c.mw.Authorize("space", "edit"), c.SetCover)
+	r.DELETE("/:uuid/media/:media", c.mw.Authenticate, c.mw.Authorize("space", "edit"), c.Delete)
+}
+
+func (c SpaceMediaController) Upload(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, utils.MaxUploadSize()+1<<20)
+
+	file, err := ctx.FormFile("file")
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": models.ErrMediaTooLarge.Error()})
+		return
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A file is required in the \"file\" field."})
+		return
+	}
+
+	res, err := c.m.Upload(ctx, ctx.Param("uuid"), file)
+
+	if errors.Is(err, models.ErrMediaTooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, utils.ErrImageTooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errors.Is(err, utils.ErrUnsupportedMedia) {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": res})
+}
+
+func (c SpaceMediaController) Serve(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+
+	local, ok := utils.Store().(*utils.LocalStorage)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Record not found."})
+		return
+	}
+
+	exists, err := c.m.Servable(ctx, key)
+	if err != nil || !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Record not found."})
+		return
+	}
+
+	path, err := local.Path(key)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Record not found."})
+		return
+	}
+
+	ctx.File(path)
+}
+
+func (c SpaceMediaController) Read(ctx *gin.Context) {
+	res, err := c.m.Read(c.sanitizeCtx(ctx))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"total": res.Count, "data": res.Items})
+}
+
+func (c SpaceMediaController) Reorder(ctx *gin.Context) {
+	var form struct {
+		Order []string `json:"order" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	res, err := c.m.Reorder(ctx, ctx.Param("uuid"), form.Order)
+
+	if errors.Is(err, models.ErrMediaOrder) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"total": res.Count, "data": res.Items})
+}
+
+func (c SpaceMediaController) SetCover(ctx *gin.Context) {
+	res, err := c.m.SetCover(ctx, ctx.Param("uuid"), ctx.Param("media"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+func (c SpaceMediaController) Delete(ctx *gin.Context) {
+	deletedAt, msg, err := c.m.Delete(ctx, ctx.Param("uuid"), ctx.Param("media"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted_at": deletedAt.String(), "message": msg})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"api/models"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -14,12 +15,12 @@ type SpaceController struct {
 }
 
 func (c SpaceController) InitSpaceController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/space", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/space", apiVersion)))
 
-	r.POST("", c.mw.Authenticate, c.Upsert)
+	r.POST("", c.mw.Authenticate, c.mw.RequireVerified, c.Upsert)
 	r.GET("/:uuid", c.mw.Authenticate, c.Read)
-	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
-	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.Authorize("space", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.Authorize("space", "update_status"), c.UpdateStatus)
 }
 
 func (c SpaceController) Upsert(ctx *gin.Context) {
@@ -31,6 +32,16 @@ func (c SpaceController) Upsert(ctx *gin.Context) {
 
 	httpStatus, res, err := c.m.Upsert(ctx, *form)
 
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(httpStatus, gin.H{"error": "You do not have permission."})
+		return
+	}
+
+	if errors.Is(err, models.ErrInvalidCoordinates) {
+		ctx.JSON(httpStatus, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err != nil {
 		c.handleError(ctx, err, c.cleanErr(err))
 		return
@@ -42,6 +53,11 @@ func (c SpaceController) Upsert(ctx *gin.Context) {
 func (c SpaceController) Read(ctx *gin.Context) {
 	res, err := c.m.Read(c.sanitizeCtx(ctx))
 
+	if errors.Is(err, models.ErrInvalidCoordinates) || errors.Is(err, models.ErrInvalidFacet) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err != nil {
 		c.handleError(ctx, err, c.cleanErr(err))
 		return
@@ -49,6 +65,10 @@ func (c SpaceController) Read(ctx *gin.Context) {
 
 	data := gin.H{"total": res.Count, "data": res.Items}
 
+	if res.Facets != nil {
+		data["facets"] = res.Facets
+	}
+
 	if ctx.Param("uuid") != "all" {
 		data = gin.H{"data": res.Item}
 	}
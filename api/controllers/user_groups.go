@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"api/models"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -14,12 +15,12 @@ type UserGroupController struct {
 }
 
 func (c UserGroupController) InitUserGroupController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/user_group", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/user_group", apiVersion)))
 
-	r.POST("", c.mw.Authenticate, c.Upsert)
+	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("user_group", "manage", "edit"), c.Upsert)
 	r.GET("/:uuid", c.mw.Authenticate, c.Read)
-	r.DELETE("/:uuid", c.mw.Authenticate, c.Delete)
-	r.PATCH("/:uuid", c.mw.Authenticate, c.UpdateStatus)
+	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("user_group", "manage", "delete"), c.Delete)
+	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("user_group", "manage", "update_status"), c.UpdateStatus)
 }
 
 func (c UserGroupController) Upsert(ctx *gin.Context) {
@@ -31,6 +32,11 @@ func (c UserGroupController) Upsert(ctx *gin.Context) {
 
 	httpStatus, res, err := c.m.Upsert(ctx, *form)
 
+	if errors.Is(err, models.ErrInvalidValidity) {
+		ctx.JSON(httpStatus, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err != nil {
 		c.handleError(ctx, err, c.cleanErr(err))
 		return
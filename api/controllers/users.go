@@ -2,25 +2,35 @@ package controllers
 
 import (
 	"api/models"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+const minPasswordLength = 8
+
 type UserController struct {
 	AppController
 	m models.User
+	s models.Session
 }
 
 func (c UserController) InitUserController(router *gin.Engine) {
-	r := router.Group(fmt.Sprintf("/%s/user", apiVersion))
+	r := c.mw.Routes(router.Group(fmt.Sprintf("/%s/user", apiVersion)))
 
 	r.POST("", c.mw.Authenticate, c.mw.CheckPermission("user", "manage", "edit"), c.Upsert)
-	// r.GET("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("user", "manage", "read"), c.Read)
-	r.GET("/:uuid", c.mw.Authenticate, c.Read)
+	r.PUT("/password", c.mw.Authenticate, c.mw.DenyImpersonation, c.ChangePassword)
+	r.PUT("/:uuid/password", c.mw.Authenticate, c.mw.DenyImpersonation, c.mw.CheckPermission("user", "manage", "edit"), c.SetPassword)
+	r.POST("/:uuid/impersonate", c.mw.Authenticate, c.mw.DenyImpersonation, c.Impersonate)
+	r.POST("/:uuid/unlock", c.mw.Authenticate, c.mw.CheckPermission("user", "manage", "edit"), c.Unlock)
+	r.GET("/:uuid", c.mw.Authenticate, c.mw.CheckPermissionOrSelf("user", "manage", "read"), c.Read)
 	r.DELETE("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("user", "manage", "delete"), c.Delete)
 	r.PATCH("/:uuid", c.mw.Authenticate, c.mw.CheckPermission("user", "manage", "update_status"), c.UpdateStatus)
+	r.GET("/:uuid/permissions/explain", c.mw.Authenticate, c.mw.CheckPermission("user", "manage", "read"), c.ExplainPermissions)
+	r.GET("/:uuid/sessions", c.mw.Authenticate, c.mw.CheckPermission("user", "manage"), c.Sessions)
+	r.DELETE("/:uuid/sessions/:session", c.mw.Authenticate, c.mw.CheckPermission("user", "manage"), c.RevokeSession)
 }
 
 func (c UserController) Upsert(ctx *gin.Context) {
@@ -40,6 +50,106 @@ func (c UserController) Upsert(ctx *gin.Context) {
 	ctx.JSON(httpStatus, gin.H{"data": res})
 }
 
+func (c UserController) SetPassword(ctx *gin.Context) {
+	var form struct {
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if len(form.Password) < minPasswordLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters.", minPasswordLength)})
+		return
+	}
+
+	if err := c.m.SetPassword(ctx, ctx.Param("uuid"), form.Password); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
+}
+
+func (c UserController) Unlock(ctx *gin.Context) {
+	if err := c.m.Unlock(ctx, ctx.Param("uuid")); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked successfully"})
+}
+
+func (c UserController) Impersonate(ctx *gin.Context) {
+	res, err := c.m.Impersonate(ctx, ctx.Param("uuid"))
+
+	if errors.Is(err, models.ErrForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can impersonate users."})
+		return
+	}
+
+	if errors.Is(err, models.ErrImpersonateSelf) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+func (c UserController) ExplainPermissions(ctx *gin.Context) {
+	res, err := c.m.ExplainPermissions(ctx, ctx.Param("uuid"))
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+func (c UserController) ChangePassword(ctx *gin.Context) {
+	var form struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	if len(form.NewPassword) < minPasswordLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters.", minPasswordLength)})
+		return
+	}
+
+	err := c.m.ChangePassword(ctx, int64(ctx.GetInt("userId")), form.CurrentPassword, form.NewPassword)
+
+	if errors.Is(err, models.ErrInvalidCredentials) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect."})
+		return
+	}
+
+	if errors.Is(err, models.ErrReauthenticationRequired) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Please sign in again before setting a password."})
+		return
+	}
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
+}
+
 func (c UserController) Read(ctx *gin.Context) {
 	res, err := c.m.Read(c.sanitizeCtx(ctx))
 
@@ -78,3 +188,24 @@ func (c UserController) UpdateStatus(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"status": status, "message": msg})
 }
+
+func (c UserController) Sessions(ctx *gin.Context) {
+	qp := c.sanitizeCtx(ctx)
+	res, err := c.s.Read(qp, qp.UUID)
+
+	if err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"total": res.Count, "data": res.Items})
+}
+
+func (c UserController) RevokeSession(ctx *gin.Context) {
+	if err := c.s.Revoke(ctx, ctx.Param("session"), ctx.Param("uuid")); err != nil {
+		c.handleError(ctx, err, c.cleanErr(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
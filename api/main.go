@@ -1,18 +1,35 @@
 package main
 
 import (
+	"api/commands"
 	"api/middleware"
+	"api/models"
 	"api/router"
 	"api/utils"
+	"log"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	cfg := utils.InitConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	utils.PingDB()
+
+	if commands.Run(os.Args[1:]) {
+		return
+	}
+
+	utils.InitStorage()
 	engine := gin.New()
 
 	middleware.SetLoggers(engine)
 	router.InitRouters(engine)
+	utils.StartKeyRotation()
+	models.StartAssignmentExpiry()
 	engine.Run(cfg.Server.Host)
 }
@@ -3,8 +3,11 @@ package middleware
 import (
 	"api/models"
 	"api/utils"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,7 +17,18 @@ import (
 
 type Middleware struct{}
 
-var limiter = rate.NewLimiter(1, 5)
+var (
+	limiter = rate.NewLimiter(1, 5)
+
+	loginLimiters   = map[string]*ipLimiter{}
+	loginLimitersMu sync.Mutex
+	loginSweep      time.Time
+)
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
 
 func SetLoggers(router *gin.Engine) {
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -35,43 +49,162 @@ func SetLoggers(router *gin.Engine) {
 }
 
 func (m Middleware) Authenticate(ctx *gin.Context) {
-	m.rateLimiter(ctx)
+	if m.rateLimiter(ctx); ctx.IsAborted() {
+		return
+	}
+
+	token := m.accessToken(ctx)
+	if models.IsAPIKey(token) {
+		m.authenticateAPIKey(ctx, token)
+		return
+	}
+
+	claims, err := utils.VerifyJWT(token, utils.AccessToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Abort()
+		return
+	}
+
+	session, err := models.Session{}.Touch(ctx, claims.SessionID)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Abort()
+		return
+	}
+
+	userPerm, _ := models.User{}.WithPermissions(ctx, func(sq *bun.SelectQuery) *bun.SelectQuery {
+		return sq.Where("u.uuid = ?", claims.UUID)
+	})
+
+	var impersonator models.User
+	if claims.Impersonator != "" {
+		impersonator, _ = models.User{}.WithPermissions(ctx, func(sq *bun.SelectQuery) *bun.SelectQuery {
+			return sq.Where("u.uuid = ?", claims.Impersonator)
+		})
+
+		if !impersonator.IsAdmin || impersonator.ID != session.UserID {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Impersonation is no longer allowed for this session."})
+			ctx.Abort()
+			return
+		}
+	}
+
+	if userPerm.ID == 0 || (impersonator.ID == 0 && userPerm.ID != session.UserID) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Your account has either been deleted or archived; please contact support for more information or assistance."})
+		ctx.Abort()
+		return
+	}
+
+	if impersonator.ID != 0 {
+		ctx.Set("impersonatorId", int(impersonator.ID))
+		ctx.Set("impersonatorUUID", impersonator.UUID)
+	}
+
+	ctx.Set("userId", int(userPerm.ID))
+	ctx.Set("userUUID", userPerm.UUID)
+	ctx.Set("sessionId", claims.SessionID)
+	ctx.Set("isAdmin", userPerm.IsAdmin)
+	ctx.Set("emailVerified", !userPerm.EmailVerifiedAt.IsZero())
+	ctx.Set("permissions", userPerm.Permissions)
+	ctx.Next()
+}
+
+func (m Middleware) AuthenticateOptional(ctx *gin.Context) {
+	if m.accessToken(ctx) == "" {
+		ctx.Next()
+		return
+	}
+
+	m.Authenticate(ctx)
+}
 
-	// if err := utils.VerifyJWT(ctx.GetHeader("Authorization"), ctx.GetHeader("UserUUID")); err != nil {
-	// 	// @todo frontend will call refresh token
-	// 	ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-	// 	ctx.Abort()
-	// 	return
-	// }
+func (m Middleware) AuthenticateEnrollment(ctx *gin.Context) {
+	claims, err := utils.VerifyJWT(m.accessToken(ctx), utils.TwoFactorEnrollToken)
+	if err != nil {
+		m.Authenticate(ctx)
+		return
+	}
+
+	userPerm, _ := models.User{}.WithPermissions(ctx, func(sq *bun.SelectQuery) *bun.SelectQuery {
+		return sq.Where("u.uuid = ?", claims.UUID)
+	})
+
+	if userPerm.ID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Your account has either been deleted or archived; please contact support for more information or assistance."})
+		ctx.Abort()
+		return
+	}
 
-	ctx.Set("userId", 1)
+	ctx.Set("userId", int(userPerm.ID))
+	ctx.Set("userUUID", userPerm.UUID)
 	ctx.Next()
 }
 
 func (m Middleware) CheckPermission(module string, perm ...string) gin.HandlerFunc {
+	items := make([]string, len(perm))
+	for i, v := range perm {
+		items[i] = module + ":" + v
+	}
+
+	utils.RegisterPermission(items...)
+
 	return func(ctx *gin.Context) {
-		items := make([]string, len(perm))
-		for i, v := range perm {
-			items[i] = module + ":" + v
+		if !m.checkPerm(items, ctx.GetStringSlice("permissions"), ctx.GetBool("isAdmin")) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
+			ctx.Abort()
+			return
 		}
 
-		var userPerm struct {
-			models.User
-			Permissions []string `bun:"permissions" json:"permissions"`
+		ctx.Next()
+	}
+}
+
+func (m Middleware) CheckOrganizationPermission(param string, perm ...string) gin.HandlerFunc {
+	items := make([]string, len(perm))
+	for i, v := range perm {
+		items[i] = "organization:" + v
+	}
+
+	utils.RegisterPermission(items...)
+
+	return func(ctx *gin.Context) {
+		if !m.checkPerm(items, ctx.GetStringSlice("permissions"), ctx.GetBool("isAdmin")) &&
+			!utils.HasPermission(models.OrganizationPermissionsFor(ctx, "organization", ctx.Param(param)), items...) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
+			ctx.Abort()
+			return
 		}
 
-		utils.GetPermissions(func(sq *bun.SelectQuery) *bun.SelectQuery {
-			return sq.Where("u.uuid = ?", ctx.GetHeader("UserUUID"))
-		}, ctx, &userPerm)
+		ctx.Next()
+	}
+}
+
+func (m Middleware) CheckPermissionOrSelf(module string, perm ...string) gin.HandlerFunc {
+	check := m.CheckPermission(module, perm...)
+
+	return func(ctx *gin.Context) {
+		if uuid := ctx.Param("uuid"); uuid != "" && uuid == ctx.GetString("userUUID") {
+			ctx.Next()
+			return
+		}
+
+		check(ctx)
+	}
+}
+
+func (m Middleware) Authorize(module, action string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		err := models.Authorize(ctx, module, action, ctx.Param("uuid"))
 
-		if userPerm.ID == 0 {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Your account has either been deleted or archived; please contact support for more information or assistance."})
+		if errors.Is(err, models.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission."})
 			ctx.Abort()
 			return
 		}
 
-		if !m.checkPerm(items, userPerm.Permissions, userPerm.IsAdmin) {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission.", "data": userPerm})
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Record not found.", "details": err.Error()})
 			ctx.Abort()
 			return
 		}
@@ -80,23 +213,84 @@ func (m Middleware) CheckPermission(module string, perm ...string) gin.HandlerFu
 	}
 }
 
-func (m Middleware) checkPerm(items, permissions []string, isAdmin bool) bool {
-	if isAdmin {
-		return true
+func (m Middleware) RequireVerified(ctx *gin.Context) {
+	if !ctx.GetBool("emailVerified") {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first."})
+		ctx.Abort()
+		return
 	}
 
-	permissionMap := make(map[string]struct{})
-	for _, p := range permissions {
-		permissionMap[p] = struct{}{}
+	ctx.Next()
+}
+
+func (m Middleware) DenyImpersonation(ctx *gin.Context) {
+	if ctx.GetInt("impersonatorId") != 0 {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "This action is not available while impersonating a user."})
+		ctx.Abort()
+		return
 	}
 
-	for _, item := range items {
-		if _, exists := permissionMap[item]; exists {
-			return true
+	ctx.Next()
+}
+
+func (m Middleware) authenticateAPIKey(ctx *gin.Context, token string) {
+	key, err := models.APIKey{}.Resolve(ctx, token)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Abort()
+		return
+	}
+
+	userPerm, _ := models.User{}.WithPermissions(ctx, func(sq *bun.SelectQuery) *bun.SelectQuery {
+		return sq.Where("u.id = ?", key.UserID)
+	})
+
+	if userPerm.ID == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Your account has either been deleted or archived; please contact support for more information or assistance."})
+		ctx.Abort()
+		return
+	}
+
+	permissions, isAdmin, err := models.APIKey{}.Grants(ctx, key, userPerm)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		ctx.Abort()
+		return
+	}
+
+	ctx.Set("userId", int(userPerm.ID))
+	ctx.Set("userUUID", userPerm.UUID)
+	ctx.Set("isAdmin", isAdmin)
+	ctx.Set("emailVerified", !userPerm.EmailVerifiedAt.IsZero())
+	ctx.Set("permissions", permissions)
+	ctx.Set("apiKeyId", int(key.ID))
+	ctx.Set("apiKeyPrefix", key.Prefix)
+	ctx.Set("apiKeyScoped", len(key.Permissions) > 0 || key.OrganizationID != 0)
+	ctx.Set("apiKeyOrganizationId", int(key.OrganizationID))
+	ctx.Next()
+
+	go models.APIKey{}.RecordUsage(ctx.Copy(), key)
+}
+
+func (m Middleware) accessToken(ctx *gin.Context) string {
+	if key := ctx.GetHeader("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	if header := ctx.GetHeader("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
 		}
+
+		return strings.TrimSpace(header)
 	}
 
-	return false
+	token, _ := ctx.Cookie("access_token")
+	return token
+}
+
+func (m Middleware) checkPerm(items, permissions []string, isAdmin bool) bool {
+	return isAdmin || utils.HasPermission(permissions, items...)
 }
 
 func (m Middleware) rateLimiter(ctx *gin.Context) {
@@ -106,3 +300,36 @@ func (m Middleware) rateLimiter(ctx *gin.Context) {
 		return
 	}
 }
+
+func (m Middleware) LoginRateLimit(ctx *gin.Context) {
+	loginLimitersMu.Lock()
+
+	ip := ctx.ClientIP()
+	l, ok := loginLimiters[ip]
+	if !ok {
+		l = &ipLimiter{limiter: rate.NewLimiter(rate.Every(2*time.Second), 10)}
+		loginLimiters[ip] = l
+	}
+
+	l.lastSeen = time.Now()
+	allowed := l.limiter.Allow()
+
+	if time.Since(loginSweep) > time.Minute {
+		for k, v := range loginLimiters {
+			if time.Since(v.lastSeen) > 10*time.Minute {
+				delete(loginLimiters, k)
+			}
+		}
+
+		loginSweep = time.Now()
+	}
+
+	loginLimitersMu.Unlock()
+
+	if !allowed {
+		ctx.Header("Retry-After", "2")
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+		ctx.Abort()
+		return
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Routes struct {
+	*gin.RouterGroup
+}
+
+func (m Middleware) Routes(group *gin.RouterGroup) Routes {
+	return Routes{group}
+}
+
+func (r Routes) Handle(method, path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	utils.BindPermissionRoute(method, r.BasePath()+path)
+	return r.RouterGroup.Handle(method, path, handlers...)
+}
+
+func (r Routes) GET(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodGet, path, handlers...)
+}
+
+func (r Routes) POST(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodPost, path, handlers...)
+}
+
+func (r Routes) PUT(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodPut, path, handlers...)
+}
+
+func (r Routes) PATCH(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodPatch, path, handlers...)
+}
+
+func (r Routes) DELETE(path string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return r.Handle(http.MethodDelete, path, handlers...)
+}
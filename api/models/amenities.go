@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	Amenity struct {
+		bun.BaseModel `bun:"table:amenities,alias:a"`
+
+		ID          int64  `bun:"id,pk,autoincrement" json:"id"`
+		Name        string `bun:"name" json:"name"`
+		Description string `bun:"description" json:"description"`
+		Icon        string `bun:"icon,nullzero" json:"icon,omitempty"`
+
+		AppModel
+	}
+)
+
+func (m Amenity) Upsert(ctx *gin.Context, item Amenity) (int, Amenity, error) {
+	var oldData *Amenity
+	httpStatus, action := 201, "POST"
+
+	var setClauseColumns = []string{"name", "description", "icon", "updated_at"}
+
+	if item.UUID != "" {
+		var tmp Amenity
+		if err := db.NewSelect().Model(&tmp).Where("uuid = ?", item.UUID).Scan(ctx); err == nil {
+			httpStatus, action, oldData = 200, "PUT", &tmp
+		}
+	}
+
+	setClause := parseSetClause(setClauseColumns)
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, oldData, item, item.ID, "amenity", action, err)
+	return httpStatus, item, err
+}
+
+func (m Amenity) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"name", "description"}
+	var allowedSortFields = map[string]bool{"name": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"name":        "text",
+		"description": "text",
+		"icon":        "text",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data Amenity
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []Amenity
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m Amenity) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	id, deletedAt, _, msg, err := setStatus(ctx, "amenities", uuid, "deleted_at")
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "amenity", "DELETE", err)
+	return
+}
+
+func (m Amenity) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
+	id, _, status, msg, err := setStatus(ctx, "amenities", uuid, "status")
+
+	go auditLog(ctx, nil, map[string]string{"status": status}, id, "amenity", "PATCH", err)
+	return
+}
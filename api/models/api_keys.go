@@ -0,0 +1,251 @@
+package models
+
+import (
+	"api/utils"
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	APIKey struct {
+		bun.BaseModel `bun:"table:api_keys,alias:ak"`
+
+		ID             int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID         int64     `bun:"user_id" json:"user_id"`
+		OrganizationID int64     `bun:"organization_id,nullzero" json:"organization_id,omitzero"`
+		Name           string    `bun:"name" json:"name"`
+		Prefix         string    `bun:"prefix" json:"prefix"`
+		KeyHash        string    `bun:"key_hash" json:"-"`
+		Permissions    []string  `bun:"permissions,type:jsonb" json:"permissions"`
+		ExpiresAt      time.Time `bun:"expires_at,nullzero,default:null" json:"expires_at,omitzero"`
+		LastUsedAt     time.Time `bun:"last_used_at,nullzero,default:null" json:"last_used_at,omitzero"`
+
+		Key string `bun:"-" json:"key,omitempty"`
+		AppModel
+	}
+)
+
+const apiKeyPrefix = "rta_"
+
+var (
+	ErrAPIKeyInvalid      = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyPermission   = errors.New("api key permissions must be a subset of your own permissions")
+	ErrAPIKeyNested       = errors.New("api keys cannot be created with an api key")
+	ErrAPIKeyOrganization = errors.New("you must manage the organization to create its api keys")
+	ErrAPIKeyScope        = errors.New("organization api keys need at least one permission")
+)
+
+func IsAPIKey(key string) bool {
+	return strings.HasPrefix(key, apiKeyPrefix)
+}
+
+func (m APIKey) Create(ctx *gin.Context, item APIKey) (int, APIKey, error) {
+	var orgPerms []string
+	if item.OrganizationID != 0 && ctx.GetInt("apiKeyId") == 0 {
+		var err error
+		if orgPerms, err = utils.OrganizationPermissions(ctx, int64(ctx.GetInt("userId")), item.OrganizationID); err != nil {
+			return 500, item, err
+		}
+	}
+
+	if err := authorizeKeyScope(ctx, item, orgPerms); err != nil {
+		return 403, item, err
+	}
+
+	prefix, err := utils.RandomToken(4)
+	if err != nil {
+		return 500, item, err
+	}
+
+	secret, err := utils.RandomToken(24)
+	if err != nil {
+		return 500, item, err
+	}
+
+	if item.Permissions == nil {
+		item.Permissions = []string{}
+	}
+
+	item.ID = 0
+	item.UUID = ""
+	item.UserID = int64(ctx.GetInt("userId"))
+	item.Prefix = apiKeyPrefix + prefix
+	item.Key = item.Prefix + "_" + secret
+	item.KeyHash = utils.HashToken(item.Key)
+	item.CreatedBy = item.UserID
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, nil, item, item.ID, "api_key", "POST", err)
+	return 201, item, err
+}
+
+func (m APIKey) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"name", "prefix"}
+	var allowedSortFields = map[string]bool{"name": true, "expires_at": true, "last_used_at": true}
+	var allowedFilterFields = map[string]string{
+		"id":           "number",
+		"user_id":      "number",
+		"name":         "text",
+		"prefix":       "text",
+		"expires_at":   "date",
+		"last_used_at": "date",
+		"created_at":   "date",
+		"updated_at":   "date",
+	}
+
+	qp.Module = "api_key"
+	policy, _ := PolicyFor(qp.Module)
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data APIKey
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Apply(policy.Scope(qp.Ctx, "read")).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []APIKey
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m APIKey) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	var item APIKey
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		q := trx.NewUpdate().Model(&item).
+			Set("deleted_at = NOW()").
+			Set("deleted_by = ?", ctx.GetInt("userId")).
+			Where("uuid = ?", uuid).
+			Where("deleted_at IS NULL").
+			Returning("id, deleted_at")
+
+		if policy, _ := PolicyFor("api_key"); !policy.Manages(ctx) {
+			q = q.Where("user_id = ?", ctx.GetInt("userId"))
+		}
+
+		res, err := q.Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrAPIKeyInvalid
+		}
+
+		return nil
+	})
+
+	deletedAt, msg = item.DeletedAt, "revoked successfully"
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, item.ID, "api_key", "DELETE", err)
+	return
+}
+
+func (m APIKey) Resolve(ctx *gin.Context, key string) (item APIKey, err error) {
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !ok {
+		return item, ErrAPIKeyInvalid
+	}
+
+	err = db.NewSelect().Model(&item).
+		Where("prefix = ?", apiKeyPrefix+prefix).
+		Where("status = 'O'").
+		Where("deleted_at IS NULL").
+		WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			return sq.Where("expires_at IS NULL").WhereOr("expires_at > NOW()")
+		}).
+		Scan(ctx)
+	if err != nil {
+		return item, ErrAPIKeyInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(item.KeyHash), []byte(utils.HashToken(key))) != 1 {
+		return item, ErrAPIKeyInvalid
+	}
+
+	return item, nil
+}
+
+func (m APIKey) Grants(ctx *gin.Context, key APIKey, user User) (permissions []string, isAdmin bool, err error) {
+	var orgPerms []string
+	if key.OrganizationID != 0 {
+		if orgPerms, err = utils.OrganizationPermissions(ctx, user.ID, key.OrganizationID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	permissions, isAdmin = keyPermissions(key, user, orgPerms)
+	return permissions, isAdmin, nil
+}
+
+func (m APIKey) RecordUsage(ctx *gin.Context, item APIKey) {
+	db.NewUpdate().Model((*APIKey)(nil)).
+		Set("last_used_at = NOW()").
+		Where("id = ?", item.ID).
+		Exec(ctx)
+
+	auditLog(ctx, nil, nil, item.ID, "api_key", ctx.Request.Method, nil)
+}
+
+func authorizeKeyScope(ctx *gin.Context, item APIKey, orgPerms []string) error {
+	if ctx.GetInt("apiKeyId") != 0 {
+		return ErrAPIKeyNested
+	}
+
+	isAdmin, owned := ctx.GetBool("isAdmin"), ctx.GetStringSlice("permissions")
+
+	if item.OrganizationID != 0 {
+		if !isAdmin && !utils.HasPermission(orgPerms, "organization:manage") {
+			return ErrAPIKeyOrganization
+		}
+
+		if len(item.Permissions) == 0 {
+			return ErrAPIKeyScope
+		}
+
+		owned = orgPerms
+	}
+
+	for _, p := range item.Permissions {
+		if !isAdmin && !utils.HasPermission(owned, p) {
+			return ErrAPIKeyPermission
+		}
+	}
+
+	return nil
+}
+
+func keyPermissions(key APIKey, user User, orgPerms []string) (permissions []string, isAdmin bool) {
+	if key.OrganizationID == 0 && len(key.Permissions) == 0 {
+		return user.Permissions, user.IsAdmin
+	}
+
+	owned := user.Permissions
+	if key.OrganizationID != 0 {
+		owned = orgPerms
+	}
+
+	return slices.DeleteFunc(slices.Clone(key.Permissions), func(p string) bool {
+		return !user.IsAdmin && !utils.HasPermission(owned, p)
+	}), false
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testContext(values map[string]any) *gin.Context {
+	gin.SetMode(gin.TestMode)
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	for k, v := range values {
+		ctx.Set(k, v)
+	}
+	return ctx
+}
+
+func TestAuthorizeKeyScope(t *testing.T) {
+	user := map[string]any{"userId": 1, "permissions": []string{"space:edit", "user:read"}}
+	admin := map[string]any{"userId": 1, "isAdmin": true}
+	viaKey := map[string]any{"userId": 1, "isAdmin": true, "apiKeyId": 9, "permissions": []string{"*"}}
+	orgPerms := []string{"organization:manage", "space:manage"}
+
+	tests := []struct {
+		name     string
+		ctx      map[string]any
+		item     APIKey
+		orgPerms []string
+		want     error
+	}{
+		{"unscoped key", user, APIKey{}, nil, nil},
+		{"subset", user, APIKey{Permissions: []string{"space:read", "user:read"}}, nil, nil},
+		{"outside subset", user, APIKey{Permissions: []string{"space:delete"}}, nil, ErrAPIKeyPermission},
+		{"admin", admin, APIKey{Permissions: []string{"role:manage"}}, nil, nil},
+		{"created with an api key", viaKey, APIKey{Permissions: []string{"space:read"}}, nil, ErrAPIKeyNested},
+		{"unscoped key created with an api key", viaKey, APIKey{}, nil, ErrAPIKeyNested},
+		{"organization key", user, APIKey{OrganizationID: 3, Permissions: []string{"space:delete"}}, orgPerms, nil},
+		{"organization key without permissions", user, APIKey{OrganizationID: 3}, orgPerms, ErrAPIKeyScope},
+		{"organization key outside organization", user, APIKey{OrganizationID: 3, Permissions: []string{"user:read"}}, orgPerms, ErrAPIKeyPermission},
+		{"organization not managed", user, APIKey{OrganizationID: 3, Permissions: []string{"space:read"}}, []string{"space:read"}, ErrAPIKeyOrganization},
+		{"admin organization key", admin, APIKey{OrganizationID: 3, Permissions: []string{"space:read"}}, nil, nil},
+		{"admin organization key beyond organization", admin, APIKey{OrganizationID: 3, Permissions: []string{"user:read"}}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := authorizeKeyScope(testContext(tt.ctx), tt.item, tt.orgPerms); !errors.Is(err, tt.want) {
+				t.Errorf("authorizeKeyScope() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyPermissions(t *testing.T) {
+	user := User{Permissions: []string{"space:edit"}}
+	admin := User{IsAdmin: true, Permissions: []string{}}
+
+	tests := []struct {
+		name      string
+		key       APIKey
+		user      User
+		orgPerms  []string
+		want      []string
+		wantAdmin bool
+	}{
+		{"unscoped", APIKey{}, user, nil, []string{"space:edit"}, false},
+		{"unscoped admin", APIKey{}, admin, nil, []string{}, true},
+		{"scoped", APIKey{Permissions: []string{"space:read", "space:delete"}}, user, nil, []string{"space:read"}, false},
+		{"scoped admin", APIKey{Permissions: []string{"role:manage"}}, admin, nil, []string{"role:manage"}, false},
+		{"organization", APIKey{OrganizationID: 3, Permissions: []string{"space:read", "space:edit"}}, user, []string{"space:read"}, []string{"space:read"}, false},
+		{"organization membership lost", APIKey{OrganizationID: 3, Permissions: []string{"space:read"}}, user, nil, []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotAdmin := keyPermissions(tt.key, tt.user, tt.orgPerms)
+
+			if !reflect.DeepEqual(got, tt.want) || gotAdmin != tt.wantAdmin {
+				t.Errorf("keyPermissions() = %v, %v, want %v, %v", got, gotAdmin, tt.want, tt.wantAdmin)
+			}
+		})
+	}
+}
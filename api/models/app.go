@@ -27,23 +27,34 @@ type (
 	}
 
 	QueryParams struct {
-		UUID        string
-		Filter      string
-		FilterExtOp string
-		FilterExt   string
-		Sort        string
-		Status      string
-		Deleted     bool
-		Limit       int
-		Page        int
-		Ctx         *gin.Context
+		UUID         string
+		Module       string
+		Filter       string
+		FilterExtOp  string
+		FilterExt    string
+		Sort         string
+		Status       string
+		Deleted      bool
+		Validity     string
+		Organization string
+		Category     string
+		Amenities    []string
+		Attributes   map[string]string
+		Near         string
+		RadiusKm     float64
+		BBox         string
+		Facets       []string
+		Limit        int
+		Page         int
+		Ctx          *gin.Context
 	}
 
 	AuditLog struct {
 		bun.BaseModel `bun:"table:audit_logs,alias:au"`
 
 		ID               int64     `bun:"id,pk,autoincrement" json:"id"`
-		UserID           int64     `bun:"user_id" json:"user_id"`
+		UserID           int64     `bun:"user_id,nullzero" json:"user_id"`
+		ImpersonatorID   int64     `bun:"impersonator_id,nullzero" json:"impersonator_id,omitempty"`
 		Token            string    `bun:"token,default:null" json:"token,omitempty"`
 		Path             string    `bun:"path" json:"path"`
 		Action           string    `bun:"action" json:"action"`
@@ -53,32 +64,26 @@ type (
 		BeforeDataChange any       `bun:"before_data_change" json:"before_data_change"`
 		AfterDataChange  any       `bun:"after_data_change" json:"after_data_change"`
 		Description      string    `bun:"description,default:null" json:"description"`
-		IPAddress        string    `bun:"ip_address" json:"ip_address"`
+		IPAddress        string    `bun:"ip_address,nullzero" json:"ip_address"`
 		UserAgent        string    `bun:"user_agent" json:"user_agent"`
 		CreatedAt        time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at,omitzero"`
 	}
 
 	Results struct {
-		Item  any
-		Items []any
-		Count int
+		Item   any
+		Items  []any
+		Count  int
+		Facets map[string][]Facet
 	}
 )
 
 var db = utils.InitDB()
 
-func sanitizeQuery(q *bun.SelectQuery, qp QueryParams, cols []string, allowedSortFields map[string]bool) *bun.SelectQuery {
-	if len(cols) > 0 {
-		var cls []string
-		for _, col := range cols {
-			cls = append(cls, "coalesce("+col+",'')")
-		}
+func sanitizeQuery(q *bun.SelectQuery, qp QueryParams, cols []string, allowedSortFields map[string]bool, allowedFilterFields map[string]string) *bun.SelectQuery {
+	q = filterQuery(q, qp, cols, allowedFilterFields)
 
-		qp.Filter = strings.ToLower(qp.Filter)
-		cl := "(" + strings.Join(cls, " || ") + ")"
-		if qp.Filter != "" {
-			q = q.Where(cl+" ~* ?", qp.Filter)
-		}
+	if s, ok := SearchableFor(qp.Module); ok {
+		q = q.Apply(s.Rank(qp))
 	}
 
 	if qp.Sort != "" {
@@ -95,14 +100,6 @@ func sanitizeQuery(q *bun.SelectQuery, qp QueryParams, cols []string, allowedSor
 		q = q.Order("created_at ASC")
 	}
 
-	if qp.Status != "A" {
-		if qp.Status == "" {
-			qp.Status = "O"
-		}
-
-		q = q.Where("status = ?", qp.Status)
-	}
-
 	if qp.Limit > 0 {
 		q = q.Limit(qp.Limit)
 
@@ -112,38 +109,48 @@ func sanitizeQuery(q *bun.SelectQuery, qp QueryParams, cols []string, allowedSor
 		}
 	}
 
-	if qp.FilterExt != "" {
-		filters := strings.Split(qp.FilterExt, ",")
-		for _, f := range filters {
-			if len(strings.Split(f, "=")) == 2 {
-				q = buildFilterExtQuery(q, filters, qp.FilterExtOp, strings.Split(f, "="))
-			}
-		}
+	return q
+}
+
+func filterQuery(q *bun.SelectQuery, qp QueryParams, cols []string, allowedFilterFields map[string]string) *bun.SelectQuery {
+	if p, ok := PolicyFor(qp.Module); ok {
+		q = q.Apply(p.Scope(qp.Ctx, "read")).Apply(p.Filter(qp))
 	}
 
-	if qp.Deleted {
-		q = q.Where("deleted_at IS NOT NULL")
-	} else {
-		q = q.Where("deleted_at IS NULL")
+	if s, ok := SearchableFor(qp.Module); ok {
+		q = q.Apply(s.Match(qp))
+	} else if len(cols) > 0 {
+		var cls []string
+		for _, col := range cols {
+			cls = append(cls, "coalesce("+col+",'')")
+		}
+
+		qp.Filter = strings.ToLower(qp.Filter)
+		cl := "(" + strings.Join(cls, " || ") + ")"
+		if qp.Filter != "" {
+			q = q.Where(cl+" ~* ?", qp.Filter)
+		}
 	}
 
-	return q
-}
+	if qp.Status != "A" {
+		if qp.Status == "" {
+			qp.Status = "O"
+		}
 
-func buildFilterExtQuery(q *bun.SelectQuery, filters []string, filterExtOp string, kv []string) *bun.SelectQuery {
-	identifier := kv[0] + " = ?"
-	var literal any = kv[1]
+		q = q.Where("status = ?", qp.Status)
+	}
 
-	if strings.Contains(kv[1], "||") {
-		identifier = kv[0] + " IN (?)"
-		literal = bun.In(strings.Split(kv[1], "||"))
+	if qp.FilterExt != "" {
+		q = q.Apply(filterExtScope(qp, allowedFilterFields))
 	}
 
-	if len(filters) > 1 && filterExtOp == "OR" {
-		return q.WhereOr(identifier, literal)
+	if qp.Deleted {
+		q = q.Where("deleted_at IS NOT NULL")
+	} else {
+		q = q.Where("deleted_at IS NULL")
 	}
 
-	return q.Where(identifier, literal)
+	return q
 }
 
 func executeTransaction(ctx context.Context, trxFunc func(*bun.Tx) error) error {
@@ -176,7 +183,8 @@ func auditLog(ctx *gin.Context, beforeDataChange, afterDataChange any, moduleId
 
 	auditLog := &AuditLog{
 		UserID:           userID,
-		Token:            "",
+		ImpersonatorID:   int64(ctx.GetInt("impersonatorId")),
+		Token:            ctx.GetString("apiKeyPrefix"),
 		Path:             ctx.FullPath(),
 		Action:           action,
 		ResponseStatus:   ctx.Writer.Status(),
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"api/utils"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var ErrInvalidValidity = errors.New("valid_until must be after valid_from")
+
+var assignmentTables = map[string]string{
+	"user_roles":  "user_role",
+	"user_groups": "user_group",
+}
+
+func validityScope(validity string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		switch validity {
+		case "upcoming":
+			return q.Where("valid_from > NOW()")
+		case "expired":
+			return q.Where("valid_until <= NOW()")
+		case "current":
+			return q.Where("valid_from IS NULL OR valid_from <= NOW()").
+				Where("valid_until IS NULL OR valid_until > NOW()")
+		default:
+			return q
+		}
+	}
+}
+
+func validateValidity(validFrom, validUntil time.Time) error {
+	if !validFrom.IsZero() && !validUntil.IsZero() && !validUntil.After(validFrom) {
+		return ErrInvalidValidity
+	}
+
+	return nil
+}
+
+func ExpireAssignments(ctx context.Context, since time.Time) (expired int, err error) {
+	for table, module := range assignmentTables {
+		var items []struct {
+			ID         int64     `bun:"id"`
+			UUID       string    `bun:"uuid" json:"uuid"`
+			UserID     int64     `bun:"user_id" json:"user_id"`
+			ValidUntil time.Time `bun:"valid_until" json:"valid_until"`
+			ExpiredAt  time.Time `bun:"expired_at" json:"expired_at"`
+		}
+
+		_, err = db.NewUpdate().
+			Table(table).
+			Set("expired_at = NOW()").
+			Where("valid_until <= NOW()").
+			Where("expired_at IS NULL").
+			Where("deleted_at IS NULL").
+			Returning("id, uuid, user_id, valid_until, expired_at").
+			Exec(ctx, &items)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, item := range items {
+			logs := &AuditLog{
+				Path:            "job:expire_assignments",
+				Action:          "EXPIRE",
+				ModuleID:        item.ID,
+				Module:          module,
+				AfterDataChange: item,
+			}
+
+			if _, err := db.NewInsert().Model(logs).Exec(ctx); err != nil {
+				log.Printf("Error writing assignment expiry audit log: %s", err)
+			}
+		}
+
+		expired += len(items)
+	}
+
+	started := 0
+	for table := range assignmentTables {
+		n, err := db.NewSelect().
+			Table(table).
+			Where("valid_from > ?", since).
+			Where("valid_from <= NOW()").
+			Where("deleted_at IS NULL").
+			Count(ctx)
+		if err != nil {
+			return expired, err
+		}
+
+		started += n
+	}
+
+	if expired > 0 || started > 0 {
+		utils.InvalidatePermissions()
+	}
+
+	return expired, nil
+}
+
+func StartAssignmentExpiry() {
+	go func() {
+		since := time.Now()
+
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			now := time.Now()
+
+			expired, err := ExpireAssignments(context.Background(), since)
+			if err != nil {
+				log.Printf("Error expiring assignments: %s", err)
+				continue
+			}
+
+			if expired > 0 {
+				log.Printf("⏳ Expired %d role/group assignment(s).", expired)
+			}
+
+			since = now
+		}
+	}()
+}
@@ -2,6 +2,10 @@ package models
 
 import (
 	"api/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
@@ -9,19 +13,169 @@ import (
 
 type Authentication struct{}
 
-func (m Authentication) Login(ctx *gin.Context, username string) (user User, err error) {
-	var userPerm struct {
-		User
-		Permissions []string `bun:"permissions" json:"permissions"`
+const dummyPasswordHash = "$2a$14$scQjC63RXxCpGSwvsliH7uc7bqpL93wzOGxwgDHoNIItQelGp2IVe"
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrAccountArchived    = errors.New("account has been archived")
+	ErrAccountDeleted     = errors.New("account has been deleted")
+	ErrEmailNotVerified   = errors.New("email address has not been verified")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+)
+
+func (m Authentication) Login(ctx *gin.Context, username, password string) (user User, err error) {
+	var attempts LoginAttempt
+	if err = attempts.CheckIP(ctx); err != nil {
+		return user, err
+	}
+
+	var tmp User
+	err = db.NewSelect().Model(&tmp).
+		WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			return sq.Where("username = ?", username).
+				WhereOr("email = ?", username)
+		}).
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil || tmp.Password == "" {
+		utils.HandlePassword("check", password, dummyPasswordHash)
+		attempts.RecordFailure(ctx, username, nil, ErrInvalidCredentials)
+		return user, ErrInvalidCredentials
+	}
+
+	if err = attempts.CheckAccount(tmp); err != nil {
+		return user, err
+	}
+
+	if _, err = utils.HandlePassword("check", password, tmp.Password); err != nil {
+		attempts.RecordFailure(ctx, username, &tmp, ErrInvalidCredentials)
+		return user, ErrInvalidCredentials
 	}
 
-	utils.GetPermissions(func(sq *bun.SelectQuery) *bun.SelectQuery {
-		return sq.Where("username = ?", username).
-			WhereOr("email = ?", username)
-	}, ctx, &userPerm)
+	return m.loadUser(ctx, tmp)
+}
 
-	user = userPerm.User
-	user.Permissions = userPerm.Permissions
+func (m Authentication) LoginTwoFactor(ctx *gin.Context, uuid, code string) (user User, err error) {
+	var attempts LoginAttempt
+	if err = attempts.CheckIP(ctx); err != nil {
+		return user, err
+	}
+
+	var tmp User
+	if err = db.NewSelect().Model(&tmp).Where("uuid = ?", uuid).Scan(ctx); err != nil {
+		return user, ErrInvalidToken
+	}
+
+	if err = attempts.CheckAccount(tmp); err != nil {
+		return user, err
+	}
+
+	if err = (TwoFactor{}).Verify(ctx, tmp, code); err != nil {
+		if errors.Is(err, ErrTwoFactorInvalidCode) {
+			attempts.RecordFailure(ctx, tmp.Username, &tmp, err)
+		}
+
+		return user, err
+	}
+
+	return m.loadUser(ctx, tmp)
+}
+
+func (m Authentication) LoginIdentity(ctx *gin.Context, tmp User) (User, error) {
+	return m.loadUser(ctx, tmp)
+}
+
+func (m Authentication) loadUser(ctx *gin.Context, tmp User) (user User, err error) {
+	if !tmp.DeletedAt.IsZero() {
+		return user, ErrAccountDeleted
+	}
+
+	if tmp.Status != "O" {
+		return user, ErrAccountArchived
+	}
+
+	if tmp.EmailVerifiedAt.IsZero() {
+		return user, ErrEmailNotVerified
+	}
+
+	return User{}.WithPermissions(ctx, func(sq *bun.SelectQuery) *bun.SelectQuery {
+		return sq.Where("u.id = ?", tmp.ID)
+	})
+}
+
+func (m Authentication) Register(ctx *gin.Context, item User, password, verifyURL string) (User, error) {
+	hashed, err := utils.HandlePassword("hash", password, "")
+	if err != nil {
+		return item, err
+	}
+
+	item.Password = hashed
+	item.UUID = ""
+	item.IsAdmin = false
+	item.EmailVerifiedAt = time.Time{}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).Exec(ctx)
+		return err
+	})
+
+	if err != nil {
+		return item, err
+	}
+
+	ctx.Set("userId", int(item.ID))
+
+	mailErr := m.SendVerification(item, verifyURL)
+	if mailErr != nil {
+		log.Printf("Error sending verification email: %s", mailErr)
+	}
+
+	go auditLog(ctx, nil, item, item.ID, "auth", "REGISTER", mailErr)
+	return item, nil
+}
+
+func (m Authentication) SendVerification(item User, verifyURL string) error {
+	token, err := utils.GenerateActionToken(item.UUID, utils.VerifyEmailToken, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s?token=%s\n\nThe link expires in 24 hours.", item.Username, verifyURL, token)
+	return utils.SendMail(item.Email, "Verify your email address", body)
+}
+
+func (m Authentication) VerifyEmail(ctx *gin.Context, token string) (err error) {
+	claims, err := utils.VerifyJWT(token, utils.VerifyEmailToken)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	var item User
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		res, err := trx.NewUpdate().Model(&item).
+			Set("email_verified_at = NOW()").
+			Set("updated_at = NOW()").
+			Where("uuid = ?", claims.UUID).
+			Where("email_verified_at IS NULL").
+			Where("deleted_at IS NULL").
+			Returning("id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrInvalidToken
+		}
+
+		return nil
+	})
+
+	if item.ID != 0 {
+		ctx.Set("userId", int(item.ID))
+		go auditLog(ctx, nil, map[string]string{"email_verified": "true"}, item.ID, "auth", "VERIFY", err)
+	}
 
-	return user, err
+	return err
 }
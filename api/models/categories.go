@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	Category struct {
+		bun.BaseModel `bun:"table:categories,alias:c"`
+
+		ID          int64  `bun:"id,pk,autoincrement" json:"id"`
+		Name        string `bun:"name" json:"name"`
+		Description string `bun:"description" json:"description"`
+
+		AppModel
+	}
+)
+
+func (m Category) Upsert(ctx *gin.Context, item Category) (int, Category, error) {
+	var oldData *Category
+	httpStatus, action := 201, "POST"
+
+	var setClauseColumns = []string{"name", "description", "updated_at"}
+
+	if item.UUID != "" {
+		var tmp Category
+		if err := db.NewSelect().Model(&tmp).Where("uuid = ?", item.UUID).Scan(ctx); err == nil {
+			httpStatus, action, oldData = 200, "PUT", &tmp
+		}
+	}
+
+	setClause := parseSetClause(setClauseColumns)
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, oldData, item, item.ID, "category", action, err)
+	return httpStatus, item, err
+}
+
+func (m Category) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"name", "description"}
+	var allowedSortFields = map[string]bool{"name": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"name":        "text",
+		"description": "text",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data Category
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []Category
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m Category) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	id, deletedAt, _, msg, err := setStatus(ctx, "categories", uuid, "deleted_at")
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "category", "DELETE", err)
+	return
+}
+
+func (m Category) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
+	id, _, status, msg, err := setStatus(ctx, "categories", uuid, "status")
+
+	go auditLog(ctx, nil, map[string]string{"status": status}, id, "category", "PATCH", err)
+	return
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	CategoryAttribute struct {
+		bun.BaseModel `bun:"table:category_attributes,alias:ca"`
+
+		ID          int64    `bun:"id,pk,autoincrement" json:"id"`
+		CategoryID  int64    `bun:"category_id" json:"category_id"`
+		Name        string   `bun:"name" json:"name"`
+		Description string   `bun:"description" json:"description"`
+		DataType    string   `bun:"data_type,default:text" json:"data_type"`
+		Options     []string `bun:"options,type:jsonb" json:"options"`
+		Required    bool     `bun:"required" json:"required"`
+
+		AppModel
+	}
+)
+
+var attributeDataTypes = []string{"text", "number", "boolean", "option"}
+
+var (
+	ErrAttributeDefinition = errors.New("data_type must be one of text, number, boolean or option, and option attributes need options")
+	ErrAttributeValue      = errors.New("value does not match the attribute data type")
+)
+
+func (m CategoryAttribute) Upsert(ctx *gin.Context, item CategoryAttribute) (int, CategoryAttribute, error) {
+	var oldData *CategoryAttribute
+	httpStatus, action := 201, "POST"
+
+	var setClauseColumns = []string{"category_id", "name", "description", "data_type", "options", "required", "updated_at"}
+
+	if item.DataType == "" {
+		item.DataType = "text"
+	}
+
+	if item.Options == nil {
+		item.Options = []string{}
+	}
+
+	if !slices.Contains(attributeDataTypes, item.DataType) || (item.DataType == "option") != (len(item.Options) > 0) {
+		return 400, item, ErrAttributeDefinition
+	}
+
+	if item.UUID != "" {
+		var tmp CategoryAttribute
+		if err := db.NewSelect().Model(&tmp).Where("uuid = ?", item.UUID).Scan(ctx); err == nil {
+			httpStatus, action, oldData = 200, "PUT", &tmp
+		}
+	}
+
+	setClause := parseSetClause(setClauseColumns)
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, oldData, item, item.ID, "category_attribute", action, err)
+	return httpStatus, item, err
+}
+
+func (m CategoryAttribute) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"name", "description", "data_type"}
+	var allowedSortFields = map[string]bool{"name": true, "data_type": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"category_id": "number",
+		"name":        "text",
+		"description": "text",
+		"data_type":   "text",
+		"required":    "bool",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data CategoryAttribute
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []CategoryAttribute
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m CategoryAttribute) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	id, deletedAt, _, msg, err := setStatus(ctx, "category_attributes", uuid, "deleted_at")
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "category_attribute", "DELETE", err)
+	return
+}
+
+func (m CategoryAttribute) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
+	id, _, status, msg, err := setStatus(ctx, "category_attributes", uuid, "status")
+
+	go auditLog(ctx, nil, map[string]string{"status": status}, id, "category_attribute", "PATCH", err)
+	return
+}
+
+func (m CategoryAttribute) normalize(value string) (string, error) {
+	switch m.DataType {
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return value, fmt.Errorf("%w: %s expects a number", ErrAttributeValue, m.Name)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return value, fmt.Errorf("%w: %s expects true or false", ErrAttributeValue, m.Name)
+		}
+		return strconv.FormatBool(b), nil
+	case "option":
+		if !slices.Contains(m.Options, value) {
+			return value, fmt.Errorf("%w: %s expects one of %v", ErrAttributeValue, m.Name, m.Options)
+		}
+	}
+
+	return value, nil
+}
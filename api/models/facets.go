@@ -0,0 +1,130 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type (
+	Facet struct {
+		Value string   `bun:"value" json:"value"`
+		Count int      `bun:"count" json:"count"`
+		Min   *float64 `bun:"-" json:"min,omitempty"`
+		Max   *float64 `bun:"-" json:"max,omitempty"`
+	}
+
+	facetDef struct {
+		Column  string
+		Bounds  []float64
+		Filters []string
+		Join    string
+	}
+)
+
+var ErrInvalidFacet = errors.New("invalid facet")
+
+var spaceFacets = map[string]facetDef{
+	"price_per_hour":  {Column: "f.price_per_hour", Bounds: []float64{0, 100, 250, 500, 1000}, Filters: []string{"price_per_hour"}},
+	"price_per_day":   {Column: "f.price_per_day", Bounds: []float64{0, 500, 1000, 2000, 5000}, Filters: []string{"price_per_day"}},
+	"price_per_month": {Column: "f.price_per_month", Bounds: []float64{0, 5000, 10000, 25000, 50000}, Filters: []string{"price_per_month"}},
+	"capacity":        {Column: "f.capacity", Bounds: []float64{0, 10, 20, 50, 100}, Filters: []string{"capacity"}},
+	"size":            {Column: "f.size", Bounds: []float64{0, 50, 100, 250, 500, 1000}, Filters: []string{"size"}},
+	"city":            {Column: "f.address->>'city'", Filters: []string{"address.city"}},
+	"availability":    {Column: "f.availability", Filters: []string{"availability"}},
+	"category": {
+		Column:  "c.name",
+		Filters: []string{"category"},
+		Join:    "JOIN space_categories sc ON sc.space_id = f.id AND sc.deleted_at IS NULL JOIN categories c ON c.id = sc.category_id AND c.deleted_at IS NULL",
+	},
+}
+
+func parseFacets(defs map[string]facetDef, names []string) ([]string, error) {
+	if slices.Contains(names, "all") {
+		names = nil
+		for name := range defs {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+	}
+
+	for _, name := range names {
+		if _, ok := defs[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFacet, name)
+		}
+	}
+
+	return names, nil
+}
+
+func (d facetDef) count(ctx context.Context, table string, base *bun.SelectQuery) (facets []Facet, err error) {
+	q := db.NewSelect().TableExpr("? AS f", bun.Ident(table)).Where("f.id IN (?)", base)
+
+	if d.Join != "" {
+		q = q.Join(d.Join)
+	}
+
+	if d.Bounds == nil {
+		err = q.ColumnExpr("? AS value", bun.Safe(d.Column)).
+			ColumnExpr("count(DISTINCT f.id) AS count").
+			Where("? IS NOT NULL", bun.Safe(d.Column)).
+			GroupExpr("value").
+			OrderExpr("count DESC, value ASC").
+			Scan(ctx, &facets)
+		return facets, err
+	}
+
+	var buckets []struct {
+		Bucket int `bun:"bucket"`
+		Count  int `bun:"count"`
+	}
+
+	err = q.ColumnExpr("width_bucket(?::float8, ?::float8[]) AS bucket", bun.Safe(d.Column), pgdialect.Array(d.Bounds)).
+		ColumnExpr("count(*) AS count").
+		Where("? IS NOT NULL", bun.Safe(d.Column)).
+		GroupExpr("bucket").
+		OrderExpr("bucket ASC").
+		Scan(ctx, &buckets)
+
+	for _, b := range buckets {
+		if b.Bucket == 0 {
+			continue
+		}
+
+		lower := d.Bounds[b.Bucket-1]
+		facet := Facet{Value: fmt.Sprintf("%g+", lower), Count: b.Count, Min: &lower}
+
+		if b.Bucket < len(d.Bounds) {
+			upper := d.Bounds[b.Bucket]
+			facet.Value, facet.Max = fmt.Sprintf("%g-%g", lower, upper), &upper
+		}
+
+		facets = append(facets, facet)
+	}
+
+	return facets, err
+}
+
+func (d facetDef) exclude(qp QueryParams) QueryParams {
+	if slices.Contains(d.Filters, "category") {
+		qp.Category = ""
+	}
+
+	var filters []string
+	for f := range strings.SplitSeq(qp.FilterExt, ",") {
+		key, _, _ := strings.Cut(f, "=")
+		if field, _, _ := strings.Cut(key, ":"); !slices.Contains(d.Filters, strings.TrimSpace(field)) {
+			filters = append(filters, f)
+		}
+	}
+
+	qp.FilterExt = strings.Join(filters, ",")
+	qp.Limit, qp.Page, qp.Sort = 0, 0, ""
+
+	return qp
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type (
+	filterClause struct {
+		Field  string
+		Column string
+		Path   []string
+		Op     string
+		Values []any
+	}
+)
+
+var ErrInvalidFilter = errors.New("invalid filterExt")
+
+var (
+	filterFieldPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-zA-Z0-9_]+)*$`)
+	filterDateLayouts  = []string{time.RFC3339, "2006-01-02"}
+	filterOps          = map[string]string{
+		"eq":      "= ?",
+		"neq":     "<> ?",
+		"gt":      "> ?",
+		"gte":     ">= ?",
+		"lt":      "< ?",
+		"lte":     "<= ?",
+		"like":    "ILIKE ?",
+		"between": "BETWEEN ? AND ?",
+	}
+)
+
+func filterExtScope(qp QueryParams, allowedFilterFields map[string]string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		clauses, err := parseFilterExt(qp.FilterExt, allowedFilterFields)
+		if err != nil {
+			return q.Err(err)
+		}
+
+		return q.WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			for _, c := range clauses {
+				expr, args := c.sql()
+				if qp.FilterExtOp == "OR" {
+					sq = sq.WhereOr(expr, args...)
+				} else {
+					sq = sq.Where(expr, args...)
+				}
+			}
+
+			return sq
+		})
+	}
+}
+
+func parseFilterExt(filterExt string, allowedFilterFields map[string]string) (clauses []filterClause, err error) {
+	for raw := range strings.SplitSeq(filterExt, ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+
+		c, err := parseFilterClause(raw, allowedFilterFields)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %s", ErrInvalidFilter, raw, err)
+		}
+
+		clauses = append(clauses, c)
+	}
+
+	return clauses, nil
+}
+
+func parseFilterClause(raw string, allowedFilterFields map[string]string) (c filterClause, err error) {
+	key, value, ok := strings.Cut(raw, "=")
+	if !ok {
+		return c, errors.New("expected field[:op]=value")
+	}
+
+	c.Field, c.Op, _ = strings.Cut(key, ":")
+	if c.Op == "" {
+		c.Op = "eq"
+	}
+
+	if !filterFieldPattern.MatchString(c.Field) {
+		return c, fmt.Errorf("malformed field %q", c.Field)
+	}
+
+	segments := strings.Split(c.Field, ".")
+	c.Column, c.Path = segments[0], segments[1:]
+
+	kind, ok := allowedFilterFields[c.Column]
+	if !ok {
+		return c, fmt.Errorf("filtering on %q is not allowed", c.Column)
+	}
+
+	if len(c.Path) > 0 && kind != "json" {
+		return c, fmt.Errorf("%q does not support path access", c.Column)
+	}
+
+	if kind == "json" {
+		if len(c.Path) == 0 {
+			return c, fmt.Errorf("%q requires a path such as %s.key", c.Column, c.Column)
+		}
+		kind = "text"
+	}
+
+	var rawValues []string
+	switch c.Op {
+	case "isnull":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return c, errors.New("isnull expects true or false")
+		}
+		c.Values = []any{b}
+		return c, nil
+	case "between":
+		lower, upper, ok := strings.Cut(value, "..")
+		if !ok {
+			return c, errors.New("between expects min..max")
+		}
+		rawValues = []string{lower, upper}
+	case "like":
+		if kind != "text" {
+			return c, errors.New("like is only supported on text fields")
+		}
+		rawValues = []string{value}
+	case "eq", "neq":
+		rawValues = strings.Split(value, "||")
+	case "gt", "gte", "lt", "lte":
+		if kind == "text" || kind == "bool" {
+			return c, fmt.Errorf("%s is only supported on number and date fields", c.Op)
+		}
+		rawValues = []string{value}
+	default:
+		return c, fmt.Errorf("unknown operator %q", c.Op)
+	}
+
+	for _, v := range rawValues {
+		typed, err := parseFilterValue(kind, v)
+		if err != nil {
+			return c, err
+		}
+		c.Values = append(c.Values, typed)
+	}
+
+	return c, nil
+}
+
+func parseFilterValue(kind, value string) (any, error) {
+	switch kind {
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return n, nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return b, nil
+	case "date":
+		for _, layout := range filterDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a date, use YYYY-MM-DD or RFC 3339", value)
+	default:
+		return value, nil
+	}
+}
+
+func (c filterClause) sql() (string, []any) {
+	column, args := "?TableAlias.?", []any{bun.Ident(c.Column)}
+	if len(c.Path) > 0 {
+		column, args = "?TableAlias.? #>> ?", append(args, pgdialect.Array(c.Path))
+	}
+
+	switch {
+	case c.Op == "isnull" && c.Values[0] == true:
+		return column + " IS NULL", args
+	case c.Op == "isnull":
+		return column + " IS NOT NULL", args
+	case c.Op == "eq" && len(c.Values) > 1:
+		return column + " IN (?)", append(args, bun.In(c.Values))
+	case c.Op == "neq" && len(c.Values) > 1:
+		return column + " NOT IN (?)", append(args, bun.In(c.Values))
+	default:
+		return column + " " + filterOps[c.Op], append(args, c.Values...)
+	}
+}
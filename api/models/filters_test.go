@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFilterFields = map[string]string{
+	"id":         "number",
+	"name":       "text",
+	"active":     "bool",
+	"address":    "json",
+	"created_at": "date",
+}
+
+func TestParseFilterClause(t *testing.T) {
+	day := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		raw     string
+		want    filterClause
+		wantErr string
+	}{
+		{raw: "id=7", want: filterClause{Field: "id", Column: "id", Path: []string{}, Op: "eq", Values: []any{7.0}}},
+		{raw: "id:neq=1||2", want: filterClause{Field: "id", Column: "id", Path: []string{}, Op: "neq", Values: []any{1.0, 2.0}}},
+		{raw: "id:between=1..10", want: filterClause{Field: "id", Column: "id", Path: []string{}, Op: "between", Values: []any{1.0, 10.0}}},
+		{raw: "id:gte=3.5", want: filterClause{Field: "id", Column: "id", Path: []string{}, Op: "gte", Values: []any{3.5}}},
+		{raw: "name:like=%loft%", want: filterClause{Field: "name", Column: "name", Path: []string{}, Op: "like", Values: []any{"%loft%"}}},
+		{raw: "name=a||b", want: filterClause{Field: "name", Column: "name", Path: []string{}, Op: "eq", Values: []any{"a", "b"}}},
+		{raw: "active=true", want: filterClause{Field: "active", Column: "active", Path: []string{}, Op: "eq", Values: []any{true}}},
+		{raw: "created_at:lt=2026-01-02", want: filterClause{Field: "created_at", Column: "created_at", Path: []string{}, Op: "lt", Values: []any{day}}},
+		{raw: "created_at:gt=2026-01-02T00:00:00Z", want: filterClause{Field: "created_at", Column: "created_at", Path: []string{}, Op: "gt", Values: []any{day}}},
+		{raw: "address.city=Manila", want: filterClause{Field: "address.city", Column: "address", Path: []string{"city"}, Op: "eq", Values: []any{"Manila"}}},
+		{raw: "name:isnull=true", want: filterClause{Field: "name", Column: "name", Path: []string{}, Op: "isnull", Values: []any{true}}},
+
+		{raw: "id", wantErr: "expected field[:op]=value"},
+		{raw: "Name=x", wantErr: "malformed field"},
+		{raw: "id;drop=1", wantErr: "malformed field"},
+		{raw: "password=x", wantErr: "is not allowed"},
+		{raw: "name.first=x", wantErr: "does not support path access"},
+		{raw: "address=x", wantErr: "requires a path"},
+		{raw: "id:regex=x", wantErr: "unknown operator"},
+		{raw: "id:like=1", wantErr: "only supported on text fields"},
+		{raw: "name:gt=a", wantErr: "only supported on number and date fields"},
+		{raw: "active:lte=true", wantErr: "only supported on number and date fields"},
+		{raw: "id:between=1", wantErr: "between expects min..max"},
+		{raw: "id=seven", wantErr: "is not a number"},
+		{raw: "active=maybe", wantErr: "is not a boolean"},
+		{raw: "created_at=yesterday", wantErr: "is not a date"},
+		{raw: "name:isnull=maybe", wantErr: "isnull expects true or false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseFilterClause(tt.raw, testFilterFields)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseFilterClause(%q) error = %v, want %q", tt.raw, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseFilterClause(%q) error = %v", tt.raw, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFilterClause(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterExt(t *testing.T) {
+	clauses, err := parseFilterExt("id:gt=1, ,name=loft", testFilterFields)
+	if err != nil {
+		t.Fatalf("parseFilterExt() error = %v", err)
+	}
+
+	if len(clauses) != 2 {
+		t.Fatalf("parseFilterExt() returned %d clauses, want 2", len(clauses))
+	}
+
+	if _, err := parseFilterExt("id=1,password=x", testFilterFields); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("parseFilterExt() error = %v, want ErrInvalidFilter", err)
+	}
+}
+
+func TestFilterExtScope(t *testing.T) {
+	tests := []struct {
+		name string
+		qp   QueryParams
+		want string
+	}{
+		{
+			name: "and",
+			qp:   QueryParams{FilterExt: "id:between=1..10,name:like=%loft%"},
+			want: `WHERE (("s"."id" BETWEEN 1 AND 10) AND ("s"."name" ILIKE '%loft%'))`,
+		},
+		{
+			name: "or",
+			qp:   QueryParams{FilterExt: "id=1||2,name:neq=a||b", FilterExtOp: "OR"},
+			want: `WHERE (("s"."id" IN (1, 2)) OR ("s"."name" NOT IN ('a', 'b')))`,
+		},
+		{
+			name: "json path and isnull",
+			qp:   QueryParams{FilterExt: "address.city=Manila,name:isnull=false"},
+			want: `WHERE (("s"."address" #>> '{"city"}' = 'Manila') AND ("s"."name" IS NOT NULL))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := db.NewSelect().Model((*Space)(nil)).Column("s.id").Join("JOIN organizations AS o ON o.id = s.organization_id").Apply(filterExtScope(tt.qp, testFilterFields)).String()
+
+			if !strings.Contains(query, tt.want) {
+				t.Errorf("query = %s, want %s", query, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"api/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,6 +39,10 @@ func (m GroupPermission) Upsert(ctx *gin.Context, item GroupPermission) (int, Gr
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "group_permission", action, err)
 	return httpStatus, item, err
 }
@@ -45,6 +50,13 @@ func (m GroupPermission) Upsert(ctx *gin.Context, item GroupPermission) (int, Gr
 func (m GroupPermission) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{}
 	var allowedSortFields = map[string]bool{}
+	var allowedFilterFields = map[string]string{
+		"id":            "number",
+		"group_id":      "number",
+		"permission_id": "number",
+		"created_at":    "date",
+		"updated_at":    "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +70,7 @@ func (m GroupPermission) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []GroupPermission
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +83,10 @@ func (m GroupPermission) Read(qp QueryParams) (res Results, err error) {
 func (m GroupPermission) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "group_permissions", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "group_permission", "DELETE", err)
 	return
 }
@@ -78,6 +94,10 @@ func (m GroupPermission) Delete(ctx *gin.Context, uuid string) (deletedAt time.T
 func (m GroupPermission) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "group_permissions", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "group_permission", "PATCH", err)
 	return
 }
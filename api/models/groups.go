@@ -1,6 +1,7 @@
 package models
 
 import (
+	"api/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,6 +39,10 @@ func (m Group) Upsert(ctx *gin.Context, item Group) (int, Group, error) {
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "group", action, err)
 	return httpStatus, item, err
 }
@@ -45,6 +50,13 @@ func (m Group) Upsert(ctx *gin.Context, item Group) (int, Group, error) {
 func (m Group) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{"name"}
 	var allowedSortFields = map[string]bool{"name": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"name":        "text",
+		"description": "text",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +70,7 @@ func (m Group) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []Group
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +83,10 @@ func (m Group) Read(qp QueryParams) (res Results, err error) {
 func (m Group) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "groups", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "group", "DELETE", err)
 	return
 }
@@ -78,6 +94,10 @@ func (m Group) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg s
 func (m Group) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "groups", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "group", "PATCH", err)
 	return
 }
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"api/utils"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	LoginAttempt struct {
+		bun.BaseModel `bun:"table:login_attempts,alias:la"`
+
+		ID        int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID    int64     `bun:"user_id,nullzero" json:"user_id,omitzero"`
+		Username  string    `bun:"username" json:"username"`
+		IPAddress string    `bun:"ip_address" json:"ip_address"`
+		UserAgent string    `bun:"user_agent" json:"user_agent"`
+		Success   bool      `bun:"success" json:"success"`
+		Reason    string    `bun:"reason,nullzero" json:"reason,omitempty"`
+		CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+	}
+
+	LockoutError struct {
+		Err        error
+		RetryAfter time.Duration
+	}
+)
+
+var (
+	ErrAccountLocked    = errors.New("account is temporarily locked")
+	ErrLoginThrottled   = errors.New("too many failed attempts, please wait before retrying")
+	ErrTooManyAttempts  = errors.New("too many failed login attempts from this address")
+	maxLoginThrottle    = 30 * time.Second
+	loginThrottleOffset = 3
+)
+
+func (e *LockoutError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LockoutError) Unwrap() error {
+	return e.Err
+}
+
+func (m LoginAttempt) CheckIP(ctx *gin.Context) error {
+	sec := utils.Security()
+
+	count, err := db.NewSelect().Model((*LoginAttempt)(nil)).
+		Where("ip_address = ?", ctx.ClientIP()).
+		Where("success = false").
+		Where("created_at > ?", time.Now().Add(-sec.FailedLoginWindow)).
+		Count(ctx)
+	if err != nil {
+		return err
+	}
+
+	if count >= sec.IPMaxFailedLogins {
+		return &LockoutError{Err: ErrTooManyAttempts, RetryAfter: sec.FailedLoginWindow}
+	}
+
+	return nil
+}
+
+func (m LoginAttempt) CheckAccount(user User) error {
+	now := time.Now()
+
+	if user.LockedUntil.After(now) {
+		return &LockoutError{Err: ErrAccountLocked, RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	if delay := loginThrottle(user.FailedLoginCount); delay > 0 {
+		if next := user.LastFailedLogin.Add(delay); next.After(now) {
+			return &LockoutError{Err: ErrLoginThrottled, RetryAfter: next.Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+func (m LoginAttempt) RecordFailure(ctx *gin.Context, username string, user *User, reason error) {
+	sec := utils.Security()
+
+	item := LoginAttempt{
+		Username:  username,
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Reason:    reason.Error(),
+	}
+
+	var locked struct {
+		FailedLoginCount int       `bun:"failed_login_count"`
+		LockedUntil      time.Time `bun:"locked_until"`
+	}
+
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		if user != nil {
+			item.UserID = user.ID
+
+			next := bun.Safe("CASE WHEN locked_until < NOW() THEN 1 ELSE failed_login_count + 1 END")
+
+			_, err := trx.NewUpdate().Model((*User)(nil)).
+				Set("failed_login_count = ?", next).
+				Set("last_failed_login = NOW()").
+				Set("locked_until = CASE WHEN ? >= ? THEN NOW() + ? * INTERVAL '1 second' WHEN locked_until < NOW() THEN NULL ELSE locked_until END", next, sec.MaxFailedLogins, int(sec.LockoutDuration.Seconds())).
+				Where("id = ?", user.ID).
+				Returning("failed_login_count, locked_until").
+				Exec(ctx, &locked)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := trx.NewInsert().Model(&item).Exec(ctx)
+		return err
+	})
+
+	if err != nil {
+		log.Printf("Error recording failed login: %s", err)
+	}
+
+	if user != nil {
+		ctx.Set("userId", int(user.ID))
+	}
+
+	go auditLog(ctx, nil, map[string]any{"username": username, "success": false}, item.UserID, "auth", "LOGIN", reason)
+
+	if user != nil && locked.FailedLoginCount >= sec.MaxFailedLogins {
+		go auditLog(ctx, nil, map[string]any{"locked_until": locked.LockedUntil}, user.ID, "auth", "LOCKED", ErrAccountLocked)
+		go m.notifyLockout(*user, locked.LockedUntil)
+	}
+}
+
+func (m LoginAttempt) RecordSuccess(ctx *gin.Context, user User) {
+	item := LoginAttempt{
+		UserID:    user.ID,
+		Username:  user.Username,
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Success:   true,
+	}
+
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().Model((*User)(nil)).
+			Set("failed_login_count = 0").
+			Set("locked_until = NULL").
+			Set("last_login = NOW()").
+			Where("id = ?", user.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = trx.NewInsert().Model(&item).Exec(ctx)
+		return err
+	})
+
+	ctx.Set("userId", int(user.ID))
+	go auditLog(ctx, nil, map[string]any{"username": user.Username, "success": true}, user.ID, "auth", "LOGIN", err)
+}
+
+func (m LoginAttempt) notifyLockout(user User, until time.Time) {
+	if user.Email == "" {
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nYour account was temporarily locked after several failed login attempts. You can try again after %s.\n\nIf this wasn't you, we recommend resetting your password.", user.Username, until.Format(time.RFC1123))
+
+	if err := utils.SendMail(user.Email, "Your account has been temporarily locked", body); err != nil {
+		log.Printf("Error sending lockout email: %s", err)
+	}
+}
+
+func loginThrottle(failures int) time.Duration {
+	if failures < loginThrottleOffset {
+		return 0
+	}
+
+	delay := time.Duration(math.Pow(2, float64(failures-loginThrottleOffset))) * time.Second
+	return min(delay, maxLoginThrottle)
+}
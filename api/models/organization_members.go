@@ -0,0 +1,205 @@
+package models
+
+import (
+	"api/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	OrganizationMember struct {
+		bun.BaseModel `bun:"table:organization_members,alias:om"`
+
+		ID              int64     `bun:"id,pk,autoincrement" json:"id"`
+		OrganizationID  int64     `bun:"organization_id" json:"organization_id"`
+		UserID          int64     `bun:"user_id,nullzero" json:"user_id,omitzero"`
+		RoleID          int64     `bun:"role_id" json:"role_id"`
+		Email           string    `bun:"email" json:"email"`
+		TokenHash       string    `bun:"token_hash,nullzero" json:"-"`
+		InvitedBy       int64     `bun:"invited_by,nullzero" json:"invited_by,omitzero"`
+		InviteExpiresAt time.Time `bun:"invite_expires_at,nullzero,default:null" json:"invite_expires_at,omitzero"`
+		AcceptedAt      time.Time `bun:"accepted_at,nullzero,default:null" json:"accepted_at,omitzero"`
+
+		AppModel
+	}
+)
+
+const organizationInviteTTL = 7 * 24 * time.Hour
+
+var (
+	ErrOrganizationRole = errors.New("role does not exist or is not an organization role")
+	ErrInviteEmail      = errors.New("invitation was sent to a different email address")
+)
+
+func memberOrganizations(userID int64) *bun.SelectQuery {
+	return db.NewSelect().
+		Table("organization_members").
+		Column("organization_id").
+		Where("user_id = ?", userID).
+		Where("accepted_at IS NOT NULL").
+		Where("deleted_at IS NULL").
+		Where("status = 'O'").
+		Where("active")
+}
+
+func (m OrganizationMember) Invite(ctx *gin.Context, orgUUID, email, roleName, acceptURL string) (int, OrganizationMember, error) {
+	var item OrganizationMember
+
+	var org Organization
+	if err := db.NewSelect().Model(&org).Where("uuid = ?", orgUUID).Where("deleted_at IS NULL").Scan(ctx); err != nil {
+		return 404, item, err
+	}
+
+	var role Role
+	err := db.NewSelect().Model(&role).
+		Where("name = ?", roleName).
+		Where("scope = 'organization'").
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		return 400, item, ErrOrganizationRole
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return 500, item, err
+	}
+
+	item = OrganizationMember{
+		OrganizationID:  org.ID,
+		RoleID:          role.ID,
+		Email:           strings.ToLower(strings.TrimSpace(email)),
+		TokenHash:       utils.HashToken(token),
+		InvitedBy:       int64(ctx.GetInt("userId")),
+		InviteExpiresAt: time.Now().Add(organizationInviteTTL),
+	}
+	item.CreatedBy = item.InvitedBy
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, nil, item, item.ID, "organization_member", "POST", err)
+
+	if err != nil {
+		return 404, item, err
+	}
+
+	body := fmt.Sprintf("Hi,\n\nYou have been invited to join %s as %s. Open the link below to accept the invitation:\n\n%s?token=%s\n\nThe link expires in 7 days.", org.Name, role.Name, acceptURL, token)
+
+	go func() {
+		if err := utils.SendMail(item.Email, "You have been invited to "+org.Name, body); err != nil {
+			log.Printf("Error sending organization invitation email: %s", err)
+		}
+	}()
+
+	return 201, item, nil
+}
+
+func (m OrganizationMember) Accept(ctx *gin.Context, token string) (item OrganizationMember, err error) {
+	userID := int64(ctx.GetInt("userId"))
+
+	var user User
+	if err = db.NewSelect().Model(&user).Column("email").Where("id = ?", userID).Scan(ctx); err != nil {
+		return item, err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		err := trx.NewSelect().Model(&item).
+			Where("token_hash = ?", utils.HashToken(token)).
+			Where("accepted_at IS NULL").
+			Where("invite_expires_at > NOW()").
+			Where("deleted_at IS NULL").
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return ErrInvalidToken
+		}
+
+		if !strings.EqualFold(item.Email, user.Email) {
+			return ErrInviteEmail
+		}
+
+		item.UserID = userID
+		item.AcceptedAt = time.Now()
+
+		_, err = trx.NewUpdate().Model(&item).
+			Column("user_id", "accepted_at").
+			Set("token_hash = NULL").
+			Set("updated_at = NOW()").
+			WherePK().
+			Exec(ctx)
+		return err
+	})
+
+	if err == nil {
+		utils.InvalidatePermissions(userID)
+	}
+
+	go auditLog(ctx, nil, item, item.ID, "organization_member", "ACCEPT", err)
+	return item, err
+}
+
+func (m OrganizationMember) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"email"}
+	var allowedSortFields = map[string]bool{"email": true, "accepted_at": true}
+	var allowedFilterFields = map[string]string{
+		"id":                "number",
+		"organization_id":   "number",
+		"user_id":           "number",
+		"role_id":           "number",
+		"email":             "text",
+		"invited_by":        "number",
+		"invite_expires_at": "date",
+		"accepted_at":       "date",
+		"created_at":        "date",
+		"updated_at":        "date",
+	}
+
+	var data []OrganizationMember
+	q := db.NewSelect().Model(&data).
+		Where("organization_id = (SELECT id FROM organizations WHERE uuid = ?)", qp.UUID)
+
+	q = sanitizeQuery(q, qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m OrganizationMember) Remove(ctx *gin.Context, orgUUID, memberUUID string) (deletedAt time.Time, err error) {
+	var item OrganizationMember
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().Model(&item).
+			Set("deleted_at = NOW()").
+			Set("deleted_by = ?", ctx.GetInt("userId")).
+			Where("uuid = ?", memberUUID).
+			Where("organization_id = (SELECT id FROM organizations WHERE uuid = ?)", orgUUID).
+			Where("deleted_at IS NULL").
+			Returning("*").
+			Exec(ctx)
+		return err
+	})
+
+	if err == nil && item.ID == 0 {
+		err = sql.ErrNoRows
+	}
+
+	if err == nil {
+		utils.InvalidatePermissions(item.UserID)
+	}
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": item.DeletedAt.String()}, item.ID, "organization_member", "DELETE", err)
+	return item.DeletedAt, err
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"api/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	Organization struct {
+		bun.BaseModel `bun:"table:organizations,alias:o"`
+
+		ID          int64  `bun:"id,pk,autoincrement" json:"id"`
+		Name        string `bun:"name" json:"name"`
+		Description string `bun:"description" json:"description"`
+
+		AppModel
+	}
+)
+
+const organizationOwnerRole = "org_owner"
+
+func (m Organization) Upsert(ctx *gin.Context, item Organization) (int, Organization, error) {
+	var oldData *Organization
+	httpStatus, action := 201, "POST"
+
+	var setClauseColumns = []string{"name", "description", "updated_at"}
+
+	if item.UUID != "" {
+		var tmp Organization
+		if err := db.NewSelect().Model(&tmp).Where("uuid = ?", item.UUID).Scan(ctx); err == nil {
+			httpStatus, action, oldData = 200, "PUT", &tmp
+		}
+	}
+
+	policy, _ := PolicyFor("organization")
+	if oldData != nil && !policy.Allows(ctx, "edit", 0, oldData.ID) {
+		return 403, item, ErrForbidden
+	}
+
+	userID := int64(ctx.GetInt("userId"))
+
+	setClause := parseSetClause(setClauseColumns)
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		if _, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx); err != nil {
+			return err
+		}
+
+		if oldData != nil {
+			return nil
+		}
+
+		var user User
+		if err := trx.NewSelect().Model(&user).Column("email").Where("id = ?", userID).Scan(ctx); err != nil {
+			return err
+		}
+
+		owner := OrganizationMember{
+			OrganizationID: item.ID,
+			UserID:         userID,
+			Email:          user.Email,
+			InvitedBy:      userID,
+			AcceptedAt:     time.Now(),
+		}
+
+		_, err := trx.NewInsert().Model(&owner).
+			Value("role_id", "(SELECT id FROM roles WHERE name = ? AND scope = 'organization')", organizationOwnerRole).
+			Exec(ctx)
+		return err
+	})
+
+	if err == nil {
+		utils.InvalidatePermissions(userID)
+	}
+
+	go auditLog(ctx, oldData, item, item.ID, "organization", action, err)
+	return httpStatus, item, err
+}
+
+func (m Organization) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"name", "description"}
+	var allowedSortFields = map[string]bool{"name": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"name":        "text",
+		"description": "text",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
+
+	qp.Module = "organization"
+	policy, _ := PolicyFor(qp.Module)
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data Organization
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Apply(policy.Scope(qp.Ctx, "read")).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []Organization
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m Organization) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	id, deletedAt, _, msg, err := setStatus(ctx, "organizations", uuid, "deleted_at")
+
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "organization", "DELETE", err)
+	return
+}
+
+func (m Organization) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
+	id, _, status, msg, err := setStatus(ctx, "organizations", uuid, "status")
+
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
+	go auditLog(ctx, nil, map[string]string{"status": status}, id, "organization", "PATCH", err)
+	return
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"api/utils"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	PasswordReset struct {
+		bun.BaseModel `bun:"table:password_resets,alias:pr"`
+
+		ID        int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID    int64     `bun:"user_id" json:"user_id"`
+		TokenHash string    `bun:"token_hash" json:"-"`
+		ExpiresAt time.Time `bun:"expires_at" json:"expires_at"`
+		UsedAt    time.Time `bun:"used_at,nullzero,default:null" json:"used_at,omitzero"`
+		IPAddress string    `bun:"ip_address" json:"ip_address"`
+		UserAgent string    `bun:"user_agent" json:"user_agent"`
+
+		AppModel
+	}
+)
+
+const passwordResetTTL = time.Hour
+
+func (m PasswordReset) Request(ctx *gin.Context, email, resetURL string) {
+	var user User
+	err := db.NewSelect().Model(&user).
+		Where("email = ?", email).
+		Where("status = 'O'").
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+
+	if err != nil {
+		go auditLog(ctx, nil, nil, 0, "auth", "FORGOT_PASSWORD", fmt.Errorf("unknown email"))
+		return
+	}
+
+	go m.issue(ctx.Copy(), user, resetURL)
+}
+
+func (m PasswordReset) issue(ctx *gin.Context, user User, resetURL string) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		log.Printf("Error generating password reset token: %s", err)
+		return
+	}
+
+	item := PasswordReset{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().Model((*PasswordReset)(nil)).
+			Set("used_at = NOW()").
+			Set("updated_at = NOW()").
+			Where("user_id = ?", user.ID).
+			Where("used_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = trx.NewInsert().Model(&item).Exec(ctx)
+		return err
+	})
+
+	ctx.Set("userId", int(user.ID))
+	auditLog(ctx, nil, map[string]string{"password_reset": item.UUID}, user.ID, "auth", "FORGOT_PASSWORD", err)
+
+	if err != nil {
+		log.Printf("Error creating password reset: %s", err)
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s?token=%s\n\nThe link expires in 1 hour. If you did not request a reset, you can ignore this email.", user.Username, resetURL, token)
+
+	if err := utils.SendMail(user.Email, "Reset your password", body); err != nil {
+		log.Printf("Error sending password reset email: %s", err)
+	}
+}
+
+func (m PasswordReset) Reset(ctx *gin.Context, token, password string) (err error) {
+	hashed, err := utils.HandlePassword("hash", password, "")
+	if err != nil {
+		return err
+	}
+
+	var item PasswordReset
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		err := trx.NewSelect().Model(&item).
+			Where("token_hash = ?", utils.HashToken(token)).
+			Where("used_at IS NULL").
+			Where("expires_at > NOW()").
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return ErrInvalidToken
+		}
+
+		res, err := trx.NewUpdate().Model((*User)(nil)).
+			Set("password = ?", hashed).
+			Set("updated_at = NOW()").
+			Where("id = ?", item.UserID).
+			Where("status = 'O'").
+			Where("deleted_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrInvalidToken
+		}
+
+		_, err = trx.NewUpdate().Model((*PasswordReset)(nil)).
+			Set("used_at = NOW()").
+			Set("updated_at = NOW()").
+			Where("user_id = ?", item.UserID).
+			Where("used_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		return revokeUserSessions(ctx, trx, item.UserID, "")
+	})
+
+	ctx.Set("userId", int(item.UserID))
+	go auditLog(ctx, nil, map[string]string{"password": "reset"}, item.UserID, "auth", "RESET_PASSWORD", err)
+
+	return err
+}
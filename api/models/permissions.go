@@ -1,6 +1,9 @@
 package models
 
 import (
+	"api/utils"
+	"context"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,8 +20,27 @@ type (
 
 		AppModel
 	}
+
+	PermissionCatalogEntry struct {
+		Name   string   `json:"name"`
+		Routes []string `json:"routes"`
+		UUID   string   `json:"uuid,omitempty"`
+		Seeded bool     `json:"seeded"`
+	}
+
+	PermissionCatalog struct {
+		Permissions []PermissionCatalogEntry `json:"permissions"`
+		Orphaned    []Permission             `json:"orphaned"`
+	}
+
+	PermissionSync struct {
+		Created  []string `json:"created"`
+		Orphaned []string `json:"orphaned"`
+	}
 )
 
+const orphanedFlag = "orphaned"
+
 func (m Permission) Upsert(ctx *gin.Context, item Permission) (int, Permission, error) {
 	var oldData *Permission
 	httpStatus, action := 201, "POST"
@@ -38,6 +60,10 @@ func (m Permission) Upsert(ctx *gin.Context, item Permission) (int, Permission,
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "permission", action, err)
 	return httpStatus, item, err
 }
@@ -45,6 +71,13 @@ func (m Permission) Upsert(ctx *gin.Context, item Permission) (int, Permission,
 func (m Permission) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{"name"}
 	var allowedSortFields = map[string]bool{"name": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"name":        "text",
+		"description": "text",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +91,7 @@ func (m Permission) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []Permission
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +104,10 @@ func (m Permission) Read(qp QueryParams) (res Results, err error) {
 func (m Permission) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "permissions", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "permission", "DELETE", err)
 	return
 }
@@ -78,6 +115,99 @@ func (m Permission) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time,
 func (m Permission) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "permissions", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "permission", "PATCH", err)
 	return
 }
+
+func (m Permission) Catalog(ctx *gin.Context) (res PermissionCatalog, err error) {
+	var rows []Permission
+	if err = db.NewSelect().Model(&rows).Where("deleted_at IS NULL").Order("name ASC").Scan(ctx); err != nil {
+		return res, err
+	}
+
+	res.Orphaned = []Permission{}
+	for _, row := range rows {
+		if row.Flag == orphanedFlag {
+			res.Orphaned = append(res.Orphaned, row)
+		}
+	}
+
+	for _, item := range utils.PermissionCatalog() {
+		entry := PermissionCatalogEntry{Name: item.Name, Routes: item.Routes}
+
+		if i := slices.IndexFunc(rows, func(p Permission) bool { return p.Name == item.Name }); i >= 0 {
+			entry.UUID, entry.Seeded = rows[i].UUID, true
+		}
+
+		res.Permissions = append(res.Permissions, entry)
+	}
+
+	return res, nil
+}
+
+func (m Permission) Sync(ctx context.Context) (res PermissionSync, err error) {
+	catalog := utils.PermissionCatalog()
+	res = PermissionSync{Created: []string{}, Orphaned: []string{}}
+
+	names := make([]string, len(catalog))
+	for i, item := range catalog {
+		names[i] = item.Name
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		for _, name := range names {
+			item := Permission{Name: name, Description: "Registered from route declarations."}
+
+			r, err := trx.NewInsert().Model(&item).On("CONFLICT (name) DO NOTHING").Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			if n, _ := r.RowsAffected(); n > 0 {
+				res.Created = append(res.Created, name)
+			}
+		}
+
+		var rows []Permission
+		if err := trx.NewSelect().Model(&rows).Where("deleted_at IS NULL").Scan(ctx); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			orphaned := !slices.ContainsFunc(names, func(name string) bool {
+				return utils.MatchPermission(row.Name, name)
+			})
+
+			flag := ""
+			if orphaned {
+				flag = orphanedFlag
+				res.Orphaned = append(res.Orphaned, row.Name)
+			}
+
+			if row.Flag == flag || (row.Flag != orphanedFlag && !orphaned) {
+				continue
+			}
+
+			_, err := trx.NewUpdate().Model((*Permission)(nil)).
+				Set("flag = NULLIF(?, '')", flag).
+				Set("updated_at = NOW()").
+				Where("id = ?", row.ID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
+	return res, err
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"api/utils"
+	"errors"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	Policy struct {
+		Module      string
+		Table       string
+		OwnerColumn string
+		OrgColumn   string
+		Public      []string
+	}
+)
+
+var ErrForbidden = errors.New("you do not have permission to access this record")
+
+var policies = map[string]Policy{
+	"space":        {Module: "space", Table: "spaces", OwnerColumn: "user_id", OrgColumn: "organization_id", Public: []string{"read"}},
+	"api_key":      {Module: "api_key", Table: "api_keys", OwnerColumn: "user_id"},
+	"organization": {Module: "organization", Table: "organizations", OrgColumn: "id"},
+}
+
+func PolicyFor(module string) (Policy, bool) {
+	p, ok := policies[module]
+	return p, ok
+}
+
+func Authorize(ctx *gin.Context, module, action, uuid string) error {
+	p, ok := PolicyFor(module)
+	if !ok || p.Manages(ctx) {
+		return nil
+	}
+
+	ownerID, organizationID, err := p.record(ctx, uuid)
+	if err != nil {
+		return err
+	}
+
+	if !p.Allows(ctx, action, ownerID, organizationID) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func OrganizationPermissionsFor(ctx *gin.Context, module, uuid string) []string {
+	p, ok := PolicyFor(module)
+	if !ok || p.OrgColumn == "" || uuid == "" || ctx.GetBool("apiKeyScoped") {
+		return nil
+	}
+
+	_, organizationID, err := p.record(ctx, uuid)
+	if err != nil || organizationID == 0 {
+		return nil
+	}
+
+	perms, _ := utils.OrganizationPermissions(ctx, int64(ctx.GetInt("userId")), organizationID)
+	return perms
+}
+
+func (p Policy) Manages(ctx *gin.Context) bool {
+	if ctx.GetInt("apiKeyOrganizationId") != 0 {
+		return false
+	}
+
+	return ctx.GetBool("isAdmin") || utils.HasPermission(ctx.GetStringSlice("permissions"), p.Module+":manage")
+}
+
+func (p Policy) Allows(ctx *gin.Context, action string, ownerID, organizationID int64) bool {
+	if p.Manages(ctx) || slices.Contains(p.Public, action) {
+		return true
+	}
+
+	scoped := ctx.GetBool("apiKeyScoped")
+	if scoped && !utils.HasPermission(ctx.GetStringSlice("permissions"), p.Module+":"+action) {
+		return false
+	}
+
+	if keyOrg := int64(ctx.GetInt("apiKeyOrganizationId")); keyOrg != 0 {
+		return organizationID == keyOrg
+	}
+
+	userID := int64(ctx.GetInt("userId"))
+	if ownerID != 0 && ownerID == userID {
+		return true
+	}
+
+	if organizationID == 0 || scoped {
+		return false
+	}
+
+	perms, err := utils.OrganizationPermissions(ctx, userID, organizationID)
+	return err == nil && utils.HasPermission(perms, p.Module+":"+action)
+}
+
+func (p Policy) Scope(ctx *gin.Context, action string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if p.Manages(ctx) {
+			return q
+		}
+
+		userID, keyOrg := ctx.GetInt("userId"), ctx.GetInt("apiKeyOrganizationId")
+		scoped := ctx.GetBool("apiKeyScoped")
+		granted := !scoped || utils.HasPermission(ctx.GetStringSlice("permissions"), p.Module+":"+action)
+
+		return q.WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			sq = sq.Where("FALSE")
+
+			if slices.Contains(p.Public, action) {
+				sq = sq.WhereGroup(" OR ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+					return sq.Where("?TableAlias.status = 'O'").Where("?TableAlias.deleted_at IS NULL")
+				})
+			}
+
+			if keyOrg != 0 {
+				if p.OrgColumn != "" && granted {
+					sq = sq.WhereOr("?TableAlias.? = ?", bun.Ident(p.OrgColumn), keyOrg)
+				}
+				return sq
+			}
+
+			if p.OwnerColumn != "" && granted {
+				sq = sq.WhereOr("?TableAlias.? = ?", bun.Ident(p.OwnerColumn), userID)
+			}
+
+			if p.OrgColumn != "" && !scoped {
+				sq = sq.WhereOr("?TableAlias.? IN (?)", bun.Ident(p.OrgColumn), memberOrganizations(int64(userID)))
+			}
+
+			return sq
+		})
+	}
+}
+
+func (p Policy) Filter(qp QueryParams) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		switch {
+		case p.OrgColumn == "" || qp.Organization == "":
+			return q
+		case qp.Organization == "mine":
+			return q.Where("?TableAlias.? IN (?)", bun.Ident(p.OrgColumn), memberOrganizations(int64(qp.Ctx.GetInt("userId"))))
+		default:
+			return q.Where("?TableAlias.? = (SELECT id FROM organizations WHERE uuid = ?)", bun.Ident(p.OrgColumn), qp.Organization)
+		}
+	}
+}
+
+func (p Policy) record(ctx *gin.Context, uuid string) (ownerID, organizationID int64, err error) {
+	var rec struct {
+		OwnerID        int64 `bun:"owner_id"`
+		OrganizationID int64 `bun:"organization_id"`
+	}
+
+	q := db.NewSelect().Table(p.Table).Where("uuid = ?", uuid)
+
+	if p.OwnerColumn != "" {
+		q = q.ColumnExpr("? AS owner_id", bun.Ident(p.OwnerColumn))
+	} else {
+		q = q.ColumnExpr("0 AS owner_id")
+	}
+
+	if p.OrgColumn != "" {
+		q = q.ColumnExpr("COALESCE(?, 0) AS organization_id", bun.Ident(p.OrgColumn))
+	} else {
+		q = q.ColumnExpr("0 AS organization_id")
+	}
+
+	err = q.Scan(ctx, &rec)
+	return rec.OwnerID, rec.OrganizationID, err
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyAPIKeyAllows(t *testing.T) {
+	space, _ := PolicyFor("space")
+
+	orgKey := map[string]any{"userId": 1, "apiKeyScoped": true, "apiKeyOrganizationId": 3, "permissions": []string{"space:manage"}}
+	userKey := map[string]any{"userId": 1, "apiKeyScoped": true, "permissions": []string{"space:edit"}}
+
+	tests := []struct {
+		name           string
+		ctx            map[string]any
+		action         string
+		ownerID, orgID int64
+		want           bool
+	}{
+		{"organization key in its organization", orgKey, "edit", 0, 3, true},
+		{"organization key in another organization", orgKey, "edit", 0, 4, false},
+		{"organization key on its owner's personal record", orgKey, "edit", 1, 0, false},
+		{"organization key without the permission", map[string]any{"userId": 1, "apiKeyScoped": true, "apiKeyOrganizationId": 3, "permissions": []string{"space:read"}}, "edit", 0, 3, false},
+		{"organization key from an admin", map[string]any{"userId": 1, "isAdmin": true, "apiKeyScoped": true, "apiKeyOrganizationId": 3, "permissions": []string{"space:edit"}}, "edit", 0, 4, false},
+		{"scoped key on own record", userKey, "edit", 1, 0, true},
+		{"scoped key on other record", userKey, "edit", 2, 0, false},
+		{"scoped key without the permission", userKey, "delete", 1, 0, false},
+		{"public action", orgKey, "read", 2, 4, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := space.Allows(testContext(tt.ctx), tt.action, tt.ownerID, tt.orgID); got != tt.want {
+				t.Errorf("Allows(%q, %d, %d) = %v, want %v", tt.action, tt.ownerID, tt.orgID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAPIKeyScope(t *testing.T) {
+	space, _ := PolicyFor("space")
+	ctx := testContext(map[string]any{"userId": 1, "apiKeyScoped": true, "apiKeyOrganizationId": 3, "permissions": []string{"space:edit"}})
+
+	query := db.NewSelect().Model((*Space)(nil)).Column("s.id").Apply(space.Scope(ctx, "edit")).String()
+
+	if !strings.Contains(query, `"s"."organization_id" = 3`) {
+		t.Errorf("query should be limited to the key's organization:\n%s", query)
+	}
+
+	if strings.Contains(query, `"s"."user_id"`) {
+		t.Errorf("query should not match the key owner's records:\n%s", query)
+	}
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"api/utils"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	RefreshToken struct {
+		bun.BaseModel `bun:"table:refresh_tokens,alias:rt"`
+
+		ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID     int64     `bun:"user_id" json:"user_id"`
+		FamilyID   string    `bun:"family_id" json:"family_id"`
+		TokenHash  string    `bun:"token_hash" json:"-"`
+		ExpiresAt  time.Time `bun:"expires_at" json:"expires_at"`
+		RevokedAt  time.Time `bun:"revoked_at,nullzero,default:null" json:"revoked_at,omitzero"`
+		ReplacedBy int64     `bun:"replaced_by,nullzero,default:null" json:"replaced_by,omitzero"`
+		IPAddress  string    `bun:"ip_address" json:"ip_address"`
+		UserAgent  string    `bun:"user_agent" json:"user_agent"`
+
+		AppModel
+	}
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+func (m RefreshToken) Issue(ctx *gin.Context, user User, device string) (tokens *utils.Tokens, err error) {
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		session, err := Session{}.create(ctx, trx, user.ID, device)
+		if err != nil {
+			return err
+		}
+
+		tokens, _, err = m.issue(ctx, trx, user, session.UUID)
+		return err
+	})
+
+	return tokens, err
+}
+
+func (m RefreshToken) Rotate(ctx *gin.Context, token string) (tokens *utils.Tokens, err error) {
+	claims, err := utils.VerifyJWT(token, utils.RefreshToken)
+	if err != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var current RefreshToken
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		err := trx.NewSelect().Model(&current).
+			Where("token_hash = ?", utils.HashToken(token)).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return ErrRefreshTokenInvalid
+		}
+
+		if !current.RevokedAt.IsZero() && current.ReplacedBy != 0 {
+			return ErrRefreshTokenReused
+		}
+
+		if !current.RevokedAt.IsZero() {
+			return ErrRefreshTokenInvalid
+		}
+
+		if current.ExpiresAt.Before(time.Now()) {
+			return ErrRefreshTokenInvalid
+		}
+
+		var user User
+		err = trx.NewSelect().Model(&user).
+			Where("id = ?", current.UserID).
+			Where("status = 'O'").
+			Where("deleted_at IS NULL").
+			Scan(ctx)
+		if err != nil || user.UUID != claims.UUID {
+			return ErrRefreshTokenInvalid
+		}
+
+		var nextID int64
+		tokens, nextID, err = m.issue(ctx, trx, user, current.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		_, err = trx.NewUpdate().Model((*RefreshToken)(nil)).
+			Set("revoked_at = NOW()").
+			Set("replaced_by = ?", nextID).
+			Set("updated_at = NOW()").
+			Where("id = ?", current.ID).
+			Exec(ctx)
+		return err
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		executeTransaction(ctx, func(trx *bun.Tx) error {
+			_, err := trx.NewUpdate().Model((*Session)(nil)).
+				Set("revoked_at = NOW()").
+				Where("uuid = ?", current.FamilyID).
+				Where("revoked_at IS NULL").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			return revokeRefreshTokens(ctx, trx, current.FamilyID)
+		})
+	}
+
+	if current.UserID != 0 {
+		ctx.Set("userId", int(current.UserID))
+		go auditLog(ctx, nil, map[string]string{"family_id": current.FamilyID}, current.UserID, "auth", "REFRESH", err)
+	}
+
+	return tokens, err
+}
+
+func (m RefreshToken) issue(ctx *gin.Context, idb bun.IDB, user User, familyID string) (*utils.Tokens, int64, error) {
+	tokens, err := utils.GenerateJWT(user.UUID, user.Username, familyID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	item := RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(tokens.RefreshToken),
+		ExpiresAt: tokens.RefreshExpiresAt.Time,
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+
+	if _, err := idb.NewInsert().Model(&item).Exec(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	_, err = idb.NewUpdate().Model((*Session)(nil)).
+		Set("expires_at = ?", item.ExpiresAt).
+		Set("last_seen_at = NOW()").
+		Set("ip_address = ?", item.IPAddress).
+		Set("user_agent = ?", item.UserAgent).
+		Where("uuid = ?", familyID).
+		Exec(ctx)
+
+	return tokens, item.ID, err
+}
+
+func revokeRefreshTokens(ctx *gin.Context, idb bun.IDB, familyID string) error {
+	_, err := idb.NewUpdate().Model((*RefreshToken)(nil)).
+		Set("revoked_at = NOW()").
+		Set("updated_at = NOW()").
+		Where("family_id = ?", familyID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+
+	return err
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"api/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,6 +39,10 @@ func (m RolePermission) Upsert(ctx *gin.Context, item RolePermission) (int, Role
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "role_permission", action, err)
 	return httpStatus, item, err
 }
@@ -45,6 +50,13 @@ func (m RolePermission) Upsert(ctx *gin.Context, item RolePermission) (int, Role
 func (m RolePermission) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{}
 	var allowedSortFields = map[string]bool{}
+	var allowedFilterFields = map[string]string{
+		"id":            "number",
+		"role_id":       "number",
+		"permission_id": "number",
+		"created_at":    "date",
+		"updated_at":    "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +70,7 @@ func (m RolePermission) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []RolePermission
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +83,10 @@ func (m RolePermission) Read(qp QueryParams) (res Results, err error) {
 func (m RolePermission) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "role_permissions", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "role_permission", "DELETE", err)
 	return
 }
@@ -78,6 +94,10 @@ func (m RolePermission) Delete(ctx *gin.Context, uuid string) (deletedAt time.Ti
 func (m RolePermission) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "role_permissions", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "role_permission", "PATCH", err)
 	return
 }
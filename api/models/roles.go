@@ -1,6 +1,8 @@
 package models
 
 import (
+	"api/utils"
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,19 +13,24 @@ type (
 	Role struct {
 		bun.BaseModel `bun:"table:roles,alias:r"`
 
-		ID          int64  `bun:"id,pk,autoincrement" json:"id"`
-		Name        string `bun:"name" json:"name"`
-		Description string `bun:"description" json:"description"`
+		ID           int64  `bun:"id,pk,autoincrement" json:"id"`
+		ParentRoleID int64  `bun:"parent_role_id,nullzero" json:"parent_role_id,omitzero"`
+		Name         string `bun:"name" json:"name"`
+		Description  string `bun:"description" json:"description"`
+		Require2FA   bool   `bun:"require_2fa" json:"require_2fa"`
+		Scope        string `bun:"scope,default:global" json:"scope"`
 
 		AppModel
 	}
 )
 
+var ErrRoleCycle = errors.New("parent role would create an inheritance cycle")
+
 func (m Role) Upsert(ctx *gin.Context, item Role) (int, Role, error) {
 	var oldData *Role
 	httpStatus, action := 201, "POST"
 
-	var setClauseColumns = []string{"name", "description", "updated_at"}
+	var setClauseColumns = []string{"parent_role_id", "name", "description", "require_2fa", "scope", "updated_at"}
 
 	if item.UUID != "" {
 		var tmp Role
@@ -32,12 +39,22 @@ func (m Role) Upsert(ctx *gin.Context, item Role) (int, Role, error) {
 		}
 	}
 
+	if oldData != nil && item.ParentRoleID != 0 {
+		if cycle, err := m.isAncestor(ctx, oldData.ID, item.ParentRoleID); err != nil || cycle {
+			return 400, item, ErrRoleCycle
+		}
+	}
+
 	setClause := parseSetClause(setClauseColumns)
 	err := executeTransaction(ctx, func(trx *bun.Tx) error {
 		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "role", action, err)
 	return httpStatus, item, err
 }
@@ -45,6 +62,15 @@ func (m Role) Upsert(ctx *gin.Context, item Role) (int, Role, error) {
 func (m Role) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{"name"}
 	var allowedSortFields = map[string]bool{"name": true}
+	var allowedFilterFields = map[string]string{
+		"id":             "number",
+		"parent_role_id": "number",
+		"name":           "text",
+		"description":    "text",
+		"scope":          "text",
+		"created_at":     "date",
+		"updated_at":     "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +84,7 @@ func (m Role) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []Role
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +97,10 @@ func (m Role) Read(qp QueryParams) (res Results, err error) {
 func (m Role) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "roles", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "role", "DELETE", err)
 	return
 }
@@ -78,6 +108,26 @@ func (m Role) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg st
 func (m Role) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "roles", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "role", "PATCH", err)
 	return
 }
+
+func (m Role) isAncestor(ctx *gin.Context, id, parentID int64) (bool, error) {
+	base := db.NewSelect().TableExpr("roles AS r").
+		ColumnExpr("r.id, r.parent_role_id").
+		Where("r.id = ?", parentID)
+
+	parents := db.NewSelect().TableExpr(`"Ancestors" AS a`).
+		ColumnExpr("r.id, r.parent_role_id").
+		Join("JOIN roles r ON r.id = a.parent_role_id")
+
+	return db.NewSelect().
+		WithRecursive("Ancestors", base.Union(parents)).
+		TableExpr(`"Ancestors"`).
+		Where("id = ?", id).
+		Exists(ctx)
+}
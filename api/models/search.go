@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun"
+)
+
+type (
+	Searchable struct {
+		Vector   string
+		Config   string
+		Headline string
+	}
+)
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+var searchables = map[string]Searchable{
+	"space": {Vector: "s.search_vector", Config: "english", Headline: "coalesce(s.name, '') || ' ' || coalesce(s.description, '')"},
+}
+
+func SearchableFor(module string) (Searchable, bool) {
+	s, ok := searchables[module]
+	return s, ok
+}
+
+func (s Searchable) Match(qp QueryParams) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		query := prefixTSQuery(qp.Filter)
+		if query == "" {
+			return q
+		}
+
+		return q.Where("? @@ to_tsquery(?, ?)", bun.Safe(s.Vector), s.Config, query)
+	}
+}
+
+func (s Searchable) Rank(qp QueryParams) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		query := prefixTSQuery(qp.Filter)
+		if query == "" {
+			return q
+		}
+
+		tsq := bun.SafeQuery("to_tsquery(?, ?)", s.Config, query)
+
+		q = q.ColumnExpr("ts_rank_cd(?, ?) AS search_rank", bun.Safe(s.Vector), tsq).
+			ColumnExpr("ts_headline(?, ?, ?, ?) AS highlight", s.Config, bun.Safe(s.Headline), tsq, headlineOptions)
+
+		if qp.Sort == "" {
+			q = q.Order("search_rank DESC")
+		}
+
+		return q
+	}
+}
+
+func prefixTSQuery(filter string) string {
+	terms := strings.FieldsFunc(strings.ToLower(filter), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	Session struct {
+		bun.BaseModel `bun:"table:sessions,alias:ss"`
+
+		ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID     int64     `bun:"user_id" json:"user_id"`
+		Device     string    `bun:"device" json:"device"`
+		IPAddress  string    `bun:"ip_address" json:"ip_address"`
+		UserAgent  string    `bun:"user_agent" json:"user_agent"`
+		LastSeenAt time.Time `bun:"last_seen_at,notnull,default:current_timestamp" json:"last_seen_at"`
+		ExpiresAt  time.Time `bun:"expires_at" json:"expires_at"`
+		RevokedAt  time.Time `bun:"revoked_at,nullzero,default:null" json:"revoked_at,omitzero"`
+
+		Current bool `bun:"-" json:"current"`
+		AppModel
+	}
+)
+
+const recentLoginWindow = 10 * time.Minute
+
+var (
+	ErrSessionRevoked           = errors.New("session has been revoked or does not exist")
+	ErrReauthenticationRequired = errors.New("please sign in again to continue")
+)
+
+func (m Session) Touch(ctx *gin.Context, uuid string) (item Session, err error) {
+	err = db.NewSelect().Model(&item).
+		Where("uuid = ?", uuid).
+		Where("revoked_at IS NULL").
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		return item, ErrSessionRevoked
+	}
+
+	if time.Since(item.LastSeenAt) > time.Minute {
+		db.NewUpdate().Model((*Session)(nil)).
+			Set("last_seen_at = NOW()").
+			Set("ip_address = ?", ctx.ClientIP()).
+			Where("id = ?", item.ID).
+			Exec(ctx)
+	}
+
+	return item, nil
+}
+
+func (m Session) Read(qp QueryParams, userUUID string) (res Results, err error) {
+	var coalesceCols = []string{"device", "user_agent", "host(ip_address)"}
+	var allowedSortFields = map[string]bool{"device": true, "last_seen_at": true, "expires_at": true}
+	var allowedFilterFields = map[string]string{
+		"id":           "number",
+		"user_id":      "number",
+		"device":       "text",
+		"ip_address":   "text",
+		"user_agent":   "text",
+		"last_seen_at": "date",
+		"expires_at":   "date",
+		"revoked_at":   "date",
+		"created_at":   "date",
+		"updated_at":   "date",
+	}
+
+	var data []Session
+	q := db.NewSelect().Model(&data).
+		Where("user_id = (SELECT id FROM users WHERE uuid = ?)", userUUID).
+		Where("revoked_at IS NULL").
+		Where("expires_at > NOW()")
+
+	if qp.Sort == "" {
+		qp.Sort = "-last_seen_at"
+	}
+
+	q = sanitizeQuery(q, qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	current := qp.Ctx.GetString("sessionId")
+	for _, item := range data {
+		item.Current = item.UUID == current
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m Session) Revoke(ctx *gin.Context, uuid, userUUID string) (err error) {
+	var item Session
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		res, err := trx.NewUpdate().Model(&item).
+			Set("revoked_at = NOW()").
+			Set("updated_at = NOW()").
+			Where("uuid = ?", uuid).
+			Where("user_id = (SELECT id FROM users WHERE uuid = ?)", userUUID).
+			Where("revoked_at IS NULL").
+			Returning("id, user_id").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrSessionRevoked
+		}
+
+		return revokeRefreshTokens(ctx, trx, uuid)
+	})
+
+	go auditLog(ctx, nil, map[string]string{"session": uuid}, item.UserID, "auth", "DELETE", err)
+	return err
+}
+
+func (m Session) create(ctx *gin.Context, idb bun.IDB, userID int64, device string) (item Session, err error) {
+	if device == "" {
+		device = deviceFromUserAgent(ctx.Request.UserAgent())
+	}
+
+	item = Session{
+		UserID:    userID,
+		Device:    device,
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		ExpiresAt: time.Now(),
+	}
+
+	_, err = idb.NewInsert().Model(&item).Returning("*").Exec(ctx)
+	return item, err
+}
+
+func requireRecentLogin(ctx *gin.Context) error {
+	exists, err := db.NewSelect().Model((*Session)(nil)).
+		Where("uuid = ?", ctx.GetString("sessionId")).
+		Where("user_id = ?", ctx.GetInt("userId")).
+		Where("revoked_at IS NULL").
+		Where("created_at > ?", time.Now().Add(-recentLoginWindow)).
+		Exists(ctx)
+	if err != nil || !exists {
+		return ErrReauthenticationRequired
+	}
+
+	return nil
+}
+
+func revokeUserSessions(ctx *gin.Context, idb bun.IDB, userID int64, keepSession string) error {
+	q := idb.NewUpdate().Model((*Session)(nil)).
+		Set("revoked_at = NOW()").
+		Set("updated_at = NOW()").
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL")
+
+	if keepSession != "" {
+		q = q.Where("uuid <> ?", keepSession)
+	}
+
+	if _, err := q.Exec(ctx); err != nil {
+		return err
+	}
+
+	q = idb.NewUpdate().Model((*RefreshToken)(nil)).
+		Set("revoked_at = NOW()").
+		Set("updated_at = NOW()").
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL")
+
+	if keepSession != "" {
+		q = q.Where("family_id <> ?", keepSession)
+	}
+
+	_, err := q.Exec(ctx)
+	return err
+}
+
+func deviceFromUserAgent(ua string) string {
+	devices := []struct{ match, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "Mac"},
+		{"Linux", "Linux"},
+	}
+
+	for _, d := range devices {
+		if strings.Contains(ua, d.match) {
+			return d.name
+		}
+	}
+
+	return "Unknown"
+}
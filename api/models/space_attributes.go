@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	SpaceAttribute struct {
+		bun.BaseModel `bun:"table:space_attributes,alias:sav"`
+
+		ID          int64  `bun:"id,pk,autoincrement" json:"id"`
+		SpaceID     int64  `bun:"space_id" json:"space_id"`
+		AttributeID int64  `bun:"attribute_id" json:"attribute_id"`
+		Value       string `bun:"value" json:"value"`
+
+		AppModel
+	}
+)
+
+var ErrAttributeCategory = errors.New("the space is not in the attribute's category")
+
+func (m SpaceAttribute) Upsert(ctx *gin.Context, item SpaceAttribute) (int, SpaceAttribute, error) {
+	var oldData *SpaceAttribute
+	httpStatus, action := 201, "POST"
+
+	var setClauseColumns = []string{"space_id", "attribute_id", "value", "updated_at"}
+
+	if item.UUID != "" {
+		var tmp SpaceAttribute
+		if err := db.NewSelect().Model(&tmp).Where("uuid = ?", item.UUID).Scan(ctx); err == nil {
+			httpStatus, action, oldData = 200, "PUT", &tmp
+		}
+	}
+
+	if oldData != nil {
+		if err := authorizeSpace(ctx, oldData.SpaceID); err != nil {
+			return 403, item, err
+		}
+	}
+
+	if err := authorizeSpace(ctx, item.SpaceID); err != nil {
+		return 403, item, err
+	}
+
+	var attr CategoryAttribute
+	err := db.NewSelect().Model(&attr).
+		Where("id = ?", item.AttributeID).
+		Where("deleted_at IS NULL").
+		Where(`EXISTS (SELECT 1 FROM space_categories sc WHERE sc.category_id = ca.category_id AND sc.space_id = ? AND sc.deleted_at IS NULL)`, item.SpaceID).
+		Scan(ctx)
+	if err != nil {
+		return 400, item, ErrAttributeCategory
+	}
+
+	if item.Value, err = attr.normalize(item.Value); err != nil {
+		return 400, item, err
+	}
+
+	setClause := parseSetClause(setClauseColumns)
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, oldData, item, item.ID, "space_attribute", action, err)
+	return httpStatus, item, err
+}
+
+func (m SpaceAttribute) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{"value"}
+	var allowedSortFields = map[string]bool{"value": true}
+	var allowedFilterFields = map[string]string{
+		"id":           "number",
+		"space_id":     "number",
+		"attribute_id": "number",
+		"value":        "text",
+		"created_at":   "date",
+		"updated_at":   "date",
+	}
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data SpaceAttribute
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []SpaceAttribute
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m SpaceAttribute) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	if err = authorizeSpaceRecord(ctx, "space_attributes", uuid); err != nil {
+		return
+	}
+
+	id, deletedAt, _, msg, err := setStatus(ctx, "space_attributes", uuid, "deleted_at")
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "space_attribute", "DELETE", err)
+	return
+}
+
+func (m SpaceAttribute) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
+	if err = authorizeSpaceRecord(ctx, "space_attributes", uuid); err != nil {
+		return
+	}
+
+	id, _, status, msg, err := setStatus(ctx, "space_attributes", uuid, "status")
+
+	go auditLog(ctx, nil, map[string]string{"status": status}, id, "space_attribute", "PATCH", err)
+	return
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	SpaceCategory struct {
+		bun.BaseModel `bun:"table:space_categories,alias:sc"`
+
+		ID         int64 `bun:"id,pk,autoincrement" json:"id"`
+		SpaceID    int64 `bun:"space_id" json:"space_id"`
+		CategoryID int64 `bun:"category_id" json:"category_id"`
+
+		AppModel
+	}
+)
+
+func (m SpaceCategory) Upsert(ctx *gin.Context, item SpaceCategory) (int, SpaceCategory, error) {
+	var oldData *SpaceCategory
+	httpStatus, action := 201, "POST"
+
+	var setClauseColumns = []string{"space_id", "category_id", "updated_at"}
+
+	if item.UUID != "" {
+		var tmp SpaceCategory
+		if err := db.NewSelect().Model(&tmp).Where("uuid = ?", item.UUID).Scan(ctx); err == nil {
+			httpStatus, action, oldData = 200, "PUT", &tmp
+		}
+	}
+
+	if oldData != nil {
+		if err := authorizeSpace(ctx, oldData.SpaceID); err != nil {
+			return 403, item, err
+		}
+	}
+
+	if err := authorizeSpace(ctx, item.SpaceID); err != nil {
+		return 403, item, err
+	}
+
+	setClause := parseSetClause(setClauseColumns)
+	err := executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, oldData, item, item.ID, "space_category", action, err)
+	return httpStatus, item, err
+}
+
+func (m SpaceCategory) Read(qp QueryParams) (res Results, err error) {
+	var coalesceCols = []string{}
+	var allowedSortFields = map[string]bool{}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"space_id":    "number",
+		"category_id": "number",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data SpaceCategory
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	var data []SpaceCategory
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
+	res.Count, err = q.ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m SpaceCategory) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
+	if err = authorizeSpaceRecord(ctx, "space_categories", uuid); err != nil {
+		return
+	}
+
+	id, deletedAt, _, msg, err := setStatus(ctx, "space_categories", uuid, "deleted_at")
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "space_category", "DELETE", err)
+	return
+}
+
+func (m SpaceCategory) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
+	if err = authorizeSpaceRecord(ctx, "space_categories", uuid); err != nil {
+		return
+	}
+
+	id, _, status, msg, err := setStatus(ctx, "space_categories", uuid, "status")
+
+	go auditLog(ctx, nil, map[string]string{"status": status}, id, "space_category", "PATCH", err)
+	return
+}
@@ -0,0 +1,321 @@
+package models
+
+import (
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	SpaceMedia struct {
+		bun.BaseModel `bun:"table:space_media,alias:sm"`
+
+		ID           int64  `bun:"id,pk,autoincrement" json:"id"`
+		SpaceID      int64  `bun:"space_id" json:"space_id"`
+		StorageKey   string `bun:"storage_key" json:"-"`
+		ThumbnailKey string `bun:"thumbnail_key" json:"-"`
+		WebPKey      string `bun:"webp_key" json:"-"`
+		ContentType  string `bun:"content_type" json:"content_type"`
+		SizeBytes    int64  `bun:"size_bytes" json:"size_bytes"`
+		Width        int    `bun:"width" json:"width"`
+		Height       int    `bun:"height" json:"height"`
+		Position     int    `bun:"position" json:"position"`
+		IsCover      bool   `bun:"is_cover" json:"is_cover"`
+		URL          string `bun:"-" json:"url"`
+		ThumbnailURL string `bun:"-" json:"thumbnail_url"`
+		WebPURL      string `bun:"-" json:"webp_url"`
+
+		AppModel
+	}
+)
+
+var (
+	ErrMediaTooLarge = errors.New("file is too large")
+	ErrMediaOrder    = errors.New("order must list every media item of the space exactly once")
+)
+
+var _ bun.AfterScanRowHook = (*SpaceMedia)(nil)
+
+func (m *SpaceMedia) AfterScanRow(ctx context.Context) error {
+	store := utils.Store()
+	m.URL, m.ThumbnailURL, m.WebPURL = store.URL(m.StorageKey), store.URL(m.ThumbnailKey), store.URL(m.WebPKey)
+	return nil
+}
+
+func (m SpaceMedia) Upload(ctx *gin.Context, spaceUUID string, file *multipart.FileHeader) (SpaceMedia, error) {
+	var item SpaceMedia
+
+	space, err := mediaSpace(ctx, spaceUUID)
+	if err != nil {
+		return item, err
+	}
+
+	maxSize := utils.MaxUploadSize()
+	if file.Size > maxSize {
+		return item, fmt.Errorf("%w, the limit is %d bytes", ErrMediaTooLarge, maxSize)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return item, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return item, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return item, fmt.Errorf("%w, the limit is %d bytes", ErrMediaTooLarge, maxSize)
+	}
+
+	img, err := utils.ProcessImage(data)
+	if err != nil {
+		return item, err
+	}
+
+	name, err := utils.RandomToken(16)
+	if err != nil {
+		return item, err
+	}
+
+	base := fmt.Sprintf("spaces/%s/%s", space.UUID, name)
+	item = SpaceMedia{
+		SpaceID:      space.ID,
+		StorageKey:   base + "." + img.Extension,
+		ThumbnailKey: base + "_thumb.jpg",
+		WebPKey:      base + ".webp",
+		ContentType:  img.ContentType,
+		SizeBytes:    int64(len(data)),
+		Width:        img.Width,
+		Height:       img.Height,
+	}
+
+	objects := []struct {
+		key, contentType string
+		body             []byte
+	}{
+		{item.StorageKey, img.ContentType, data},
+		{item.ThumbnailKey, "image/jpeg", img.Thumbnail},
+		{item.WebPKey, "image/webp", img.WebP},
+	}
+
+	store := utils.Store()
+	var stored []string
+	for _, o := range objects {
+		if err = store.Put(ctx, o.key, o.body, o.contentType); err != nil {
+			break
+		}
+		stored = append(stored, o.key)
+	}
+
+	if err == nil {
+		item.CreatedBy = int64(ctx.GetInt("userId"))
+		err = executeTransaction(ctx, func(trx *bun.Tx) error {
+			if _, err := trx.NewSelect().Table("spaces").Column("id").Where("id = ?", space.ID).For("UPDATE").Exec(ctx); err != nil {
+				return err
+			}
+
+			err := trx.NewSelect().Table("space_media").
+				ColumnExpr("COALESCE(MAX(position) + 1, 0)").
+				ColumnExpr("COUNT(*) FILTER (WHERE is_cover) = 0").
+				Where("space_id = ?", space.ID).
+				Where("deleted_at IS NULL").
+				Scan(ctx, &item.Position, &item.IsCover)
+			if err != nil {
+				return err
+			}
+
+			_, err = trx.NewInsert().Model(&item).Returning("*").Exec(ctx)
+			return err
+		})
+	}
+
+	if err != nil {
+		for _, key := range stored {
+			store.Delete(context.WithoutCancel(ctx), key)
+		}
+		return item, err
+	}
+
+	item.AfterScanRow(ctx)
+
+	go auditLog(ctx, nil, item, item.ID, "space_media", "POST", err)
+	return item, nil
+}
+
+func (m SpaceMedia) Read(qp QueryParams) (res Results, err error) {
+	var data []SpaceMedia
+	res.Count, err = db.NewSelect().Model(&data).
+		Where("sm.space_id IN (?)", visibleSpaces(qp.Ctx).Where("s.uuid = ?", qp.UUID)).
+		Where("sm.deleted_at IS NULL").
+		Order("sm.position ASC", "sm.id ASC").
+		ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	return res, err
+}
+
+func (m SpaceMedia) Reorder(ctx *gin.Context, spaceUUID string, order []string) (res Results, err error) {
+	space, err := mediaSpace(ctx, spaceUUID)
+	if err != nil {
+		return res, err
+	}
+
+	var current []string
+	err = db.NewSelect().Table("space_media").Column("uuid").
+		Where("space_id = ?", space.ID).
+		Where("deleted_at IS NULL").
+		Scan(ctx, &current)
+	if err != nil {
+		return res, err
+	}
+
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	slices.Sort(current)
+	if !slices.Equal(sorted, current) {
+		return res, ErrMediaOrder
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		for position, uuid := range order {
+			_, err := trx.NewUpdate().Table("space_media").
+				Set("position = ?", position).
+				Set("updated_at = NOW()").
+				Set("updated_by = ?", ctx.GetInt("userId")).
+				Where("uuid = ?", uuid).
+				Where("space_id = ?", space.ID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	go auditLog(ctx, nil, map[string][]string{"order": order}, space.ID, "space_media", "PUT", err)
+	if err != nil {
+		return res, err
+	}
+
+	return m.Read(QueryParams{Ctx: ctx, UUID: spaceUUID})
+}
+
+func (m SpaceMedia) SetCover(ctx *gin.Context, spaceUUID, uuid string) (SpaceMedia, error) {
+	var item SpaceMedia
+
+	space, err := mediaSpace(ctx, spaceUUID)
+	if err != nil {
+		return item, err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		if err := trx.NewSelect().Model(&item).Where("uuid = ?", uuid).Where("space_id = ?", space.ID).Where("deleted_at IS NULL").For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if _, err := trx.NewUpdate().Table("space_media").Set("is_cover = false").Where("space_id = ?", space.ID).Where("is_cover").Exec(ctx); err != nil {
+			return err
+		}
+
+		_, err := trx.NewUpdate().Table("space_media").
+			Set("is_cover = true").
+			Set("updated_at = NOW()").
+			Set("updated_by = ?", ctx.GetInt("userId")).
+			Where("id = ?", item.ID).
+			Exec(ctx)
+		return err
+	})
+
+	item.IsCover = err == nil
+
+	go auditLog(ctx, nil, map[string]bool{"is_cover": true}, item.ID, "space_media", "PATCH", err)
+	return item, err
+}
+
+func (m SpaceMedia) Delete(ctx *gin.Context, spaceUUID, uuid string) (deletedAt time.Time, msg string, err error) {
+	space, err := mediaSpace(ctx, spaceUUID)
+	if err != nil {
+		return
+	}
+
+	var temp struct {
+		ID        int64     `bun:"id"`
+		DeletedAt time.Time `bun:"deleted_at"`
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().
+			Table("space_media").
+			Where("uuid = ?", uuid).
+			Where("space_id = ?", space.ID).
+			Set("deleted_at = CASE WHEN deleted_at IS NULL THEN NOW() ELSE NULL END").
+			Set("deleted_by = ?", ctx.GetInt("userId")).
+			Set("is_cover = false").
+			Returning("id, deleted_at").
+			Exec(ctx, &temp)
+		if err != nil {
+			return err
+		}
+
+		_, err = trx.NewUpdate().Table("space_media").
+			Set("is_cover = true").
+			Where(`id = (
+				SELECT id FROM space_media
+				WHERE space_id = ? AND deleted_at IS NULL
+				ORDER BY position ASC, id ASC LIMIT 1
+			)`, space.ID).
+			Where("NOT EXISTS (SELECT 1 FROM space_media WHERE space_id = ? AND is_cover AND deleted_at IS NULL)", space.ID).
+			Exec(ctx)
+		return err
+	})
+
+	msg = "restored successfully"
+	if !temp.DeletedAt.IsZero() {
+		deletedAt = temp.DeletedAt
+		msg = "deleted successfully"
+	}
+
+	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, temp.ID, "space_media", "DELETE", err)
+	return
+}
+
+func (m SpaceMedia) Servable(ctx *gin.Context, key string) (bool, error) {
+	return db.NewSelect().Model((*SpaceMedia)(nil)).
+		Where("sm.space_id IN (?)", visibleSpaces(ctx)).
+		Where("sm.deleted_at IS NULL").
+		Where("? IN (sm.storage_key, sm.thumbnail_key, sm.webp_key)", key).
+		Exists(ctx)
+}
+
+func mediaSpace(ctx context.Context, uuid string) (space Space, err error) {
+	err = db.NewSelect().Model(&space).Column("id", "uuid").Where("uuid = ?", uuid).Where("deleted_at IS NULL").Scan(ctx)
+	return
+}
+
+func visibleSpaces(ctx *gin.Context) *bun.SelectQuery {
+	policy, _ := PolicyFor("space")
+
+	return db.NewSelect().Model((*Space)(nil)).
+		Column("s.id").
+		Where("s.deleted_at IS NULL").
+		Apply(policy.Scope(ctx, "read"))
+}
+
+func spaceMediaRelation(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Where("sm.deleted_at IS NULL").Order("sm.position ASC", "sm.id ASC")
+}
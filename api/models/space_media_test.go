@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVisibleSpaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     map[string]any
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "anonymous",
+			ctx:  map[string]any{},
+			want: []string{`"s".status = 'O'`, `"s"."user_id" = 0`},
+		},
+		{
+			name: "owner",
+			ctx:  map[string]any{"userId": 7},
+			want: []string{`"s".status = 'O'`, `"s"."user_id" = 7`, "organization_members"},
+		},
+		{
+			name:    "manager",
+			ctx:     map[string]any{"userId": 7, "permissions": []string{"space:manage"}},
+			notWant: []string{`"s".status = 'O'`, `"s"."user_id"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := db.NewSelect().Model((*SpaceMedia)(nil)).Column("sm.id").
+				Where("sm.space_id IN (?)", visibleSpaces(testContext(tt.ctx))).
+				String()
+
+			if !strings.Contains(query, "s.deleted_at IS NULL") {
+				t.Errorf("query should skip deleted spaces:\n%s", query)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(query, want) {
+					t.Errorf("query missing %q:\n%s", want, query)
+				}
+			}
+
+			for _, notWant := range tt.notWant {
+				if strings.Contains(query, notWant) {
+					t.Errorf("query should not contain %q:\n%s", notWant, query)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,31 +16,48 @@ type (
 	Space struct {
 		bun.BaseModel `bun:"table:spaces,alias:s"`
 
-		ID            int64           `bun:"id,pk,autoincrement" json:"id"`
-		UserID        int64           `bun:"user_id" json:"user_id"`
-		Name          string          `bun:"name" json:"name"`
-		Description   string          `bun:"description" json:"description"`
-		Address       *map[string]any `bun:"address,type:jsonb" json:"address"`
-		PricePerHour  float64         `bun:"price_per_hour,nullzero,default:0" json:"price_per_hour"`
-		PricePerDay   float64         `bun:"price_per_day,nullzero,default:0" json:"price_per_day"`
-		PricePerMonth float64         `bun:"price_per_month,nullzero,default:0" json:"price_per_month"`
-		Size          float64         `bun:"size,nullzero,default:0" json:"size"`
-		Capacity      int64           `bun:"capacity,nullzero,default:0" json:"capacity"`
-		Availability  string          `bun:"availability,default:A" json:"availability"`
+		ID             int64           `bun:"id,pk,autoincrement" json:"id"`
+		UserID         int64           `bun:"user_id" json:"user_id"`
+		OrganizationID int64           `bun:"organization_id,nullzero" json:"organization_id,omitzero"`
+		Name           string          `bun:"name" json:"name"`
+		Description    string          `bun:"description" json:"description"`
+		Address        *map[string]any `bun:"address,type:jsonb" json:"address"`
+		Latitude       *float64        `bun:"latitude" json:"latitude"`
+		Longitude      *float64        `bun:"longitude" json:"longitude"`
+		PricePerHour   float64         `bun:"price_per_hour,nullzero,default:0" json:"price_per_hour"`
+		PricePerDay    float64         `bun:"price_per_day,nullzero,default:0" json:"price_per_day"`
+		PricePerMonth  float64         `bun:"price_per_month,nullzero,default:0" json:"price_per_month"`
+		Size           float64         `bun:"size,nullzero,default:0" json:"size"`
+		Capacity       int64           `bun:"capacity,nullzero,default:0" json:"capacity"`
+		Availability   string          `bun:"availability,default:A" json:"availability"`
+		DistanceKm     *float64        `bun:"distance_km,scanonly" json:"distance_km,omitempty"`
+		SearchRank     float64         `bun:"search_rank,scanonly" json:"search_rank,omitempty"`
+		Highlight      string          `bun:"highlight,scanonly" json:"highlight,omitempty"`
+		Media          []SpaceMedia    `bun:"rel:has-many,join:id=space_id" json:"media"`
 
 		AppModel
 	}
 )
 
+const (
+	defaultSearchRadiusKm = 25
+	maxSearchRadiusKm     = 500
+)
+
+var ErrInvalidCoordinates = errors.New("invalid coordinates")
+
 func (m Space) Upsert(ctx *gin.Context, item Space) (int, Space, error) {
 	var oldData *Space
 	httpStatus, action := 201, "POST"
 
 	var setClauseColumns = []string{
 		"user_id",
+		"organization_id",
 		"name",
 		"description",
 		"address",
+		"latitude",
+		"longitude",
 		"price_per_hour",
 		"price_per_day",
 		"price_per_month",
@@ -52,6 +74,26 @@ func (m Space) Upsert(ctx *gin.Context, item Space) (int, Space, error) {
 		}
 	}
 
+	if err := validateCoordinates(item.Latitude, item.Longitude); err != nil {
+		return 400, item, err
+	}
+
+	policy, _ := PolicyFor("space")
+	if oldData != nil && !policy.Allows(ctx, "edit", oldData.UserID, oldData.OrganizationID) {
+		return 403, item, ErrForbidden
+	}
+
+	if item.OrganizationID != 0 && (oldData == nil || item.OrganizationID != oldData.OrganizationID) && !policy.Allows(ctx, "edit", 0, item.OrganizationID) {
+		return 403, item, ErrForbidden
+	}
+
+	if !policy.Manages(ctx) || item.UserID == 0 {
+		item.UserID = int64(ctx.GetInt("userId"))
+		if oldData != nil {
+			item.UserID = oldData.UserID
+		}
+	}
+
 	setClause := parseSetClause(setClauseColumns)
 	err := executeTransaction(ctx, func(trx *bun.Tx) error {
 		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
@@ -63,23 +105,79 @@ func (m Space) Upsert(ctx *gin.Context, item Space) (int, Space, error) {
 }
 
 func (m Space) Read(qp QueryParams) (res Results, err error) {
-	var coalesceCols = []string{
-		"name",
-		"description",
-		"address->>'city'",
-		"address->>'country'",
-		"address->>'postal_code'",
-		"address->>'street'",
-		"address->>'latitude'",
-		"address->>'longitude'",
-		"price_per_hour::text",
-		"price_per_day::text",
-		"price_per_month::text",
-		"size::text",
-		"capacity::text",
-		"availability",
+	qp.Module = "space"
+	policy, _ := PolicyFor(qp.Module)
+
+	q := db.NewSelect()
+
+	if qp.UUID != "all" {
+		var data Space
+		err = q.Model(&data).Where("uuid = ?", qp.UUID).Apply(policy.Scope(qp.Ctx, "read")).Relation("Media", spaceMediaRelation).Scan(qp.Ctx)
+
+		res.Item = data
+
+		return res, err
+	}
+
+	facets, err := parseFacets(spaceFacets, qp.Facets)
+	if err != nil {
+		return res, err
+	}
+
+	var data []Space
+	if q, err = m.listQuery(qp, &data); err != nil {
+		return res, err
+	}
+
+	res.Count, err = q.Relation("Media", spaceMediaRelation).ScanAndCount(qp.Ctx)
+
+	for _, item := range data {
+		res.Items = append(res.Items, item)
+	}
+
+	if err != nil || len(facets) == 0 {
+		return res, err
+	}
+
+	res.Facets = map[string][]Facet{}
+	for _, name := range facets {
+		def := spaceFacets[name]
+
+		base, err := m.facetQuery(def.exclude(qp))
+		if err != nil {
+			return res, err
+		}
+
+		if res.Facets[name], err = def.count(qp.Ctx, "spaces", base); err != nil {
+			return res, err
+		}
 	}
 
+	return res, nil
+}
+
+var spaceFilterFields = map[string]string{
+	"id":              "number",
+	"user_id":         "number",
+	"organization_id": "number",
+	"name":            "text",
+	"description":     "text",
+	"address":         "json",
+	"latitude":        "number",
+	"longitude":       "number",
+	"price_per_hour":  "number",
+	"price_per_day":   "number",
+	"price_per_month": "number",
+	"size":            "number",
+	"capacity":        "number",
+	"availability":    "text",
+	"created_at":      "date",
+	"updated_at":      "date",
+}
+
+func (m Space) listQuery(qp QueryParams, model any) (*bun.SelectQuery, error) {
+	var coalesceCols = []string{}
+
 	var allowedSortFields = map[string]bool{
 		"name":            true,
 		"address":         true,
@@ -91,26 +189,27 @@ func (m Space) Read(qp QueryParams) (res Results, err error) {
 		"availability":    true,
 	}
 
-	q := db.NewSelect()
-
-	if qp.UUID != "all" {
-		var data Space
-		err = q.Model(&data).Where("uuid = ?", qp.UUID).Scan(qp.Ctx)
-
-		res.Item = data
+	geo, err := spaceGeoFilter(qp)
+	if err != nil {
+		return nil, err
+	}
 
-		return res, err
+	if qp.Near != "" {
+		allowedSortFields["distance_km"] = true
 	}
 
-	var data []Space
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
-	res.Count, err = q.ScanAndCount(qp.Ctx)
+	q := db.NewSelect().Model(model).ColumnExpr("?TableColumns").Apply(spaceTaxonomyFilter(qp), geo, spaceDistance(qp))
+	return sanitizeQuery(q, qp, coalesceCols, allowedSortFields, spaceFilterFields), nil
+}
 
-	for _, item := range data {
-		res.Items = append(res.Items, item)
+func (m Space) facetQuery(qp QueryParams) (*bun.SelectQuery, error) {
+	geo, err := spaceGeoFilter(qp)
+	if err != nil {
+		return nil, err
 	}
 
-	return res, err
+	q := db.NewSelect().Model((*Space)(nil)).Column("s.id").Apply(spaceTaxonomyFilter(qp), geo)
+	return filterQuery(q, qp, nil, spaceFilterFields), nil
 }
 
 func (m Space) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
@@ -126,3 +225,161 @@ func (m Space) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string,
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "space", "PATCH", err)
 	return
 }
+
+func spaceTaxonomyFilter(qp QueryParams) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if qp.Category != "" {
+			q = q.Where(`s.id IN (
+				SELECT sc.space_id FROM space_categories sc
+				JOIN categories c ON c.id = sc.category_id AND c.deleted_at IS NULL
+				WHERE sc.deleted_at IS NULL AND (c.uuid::text = ? OR c.name = ?)
+			)`, qp.Category, qp.Category)
+		}
+
+		if len(qp.Amenities) > 0 {
+			q = q.Where(`s.id IN (
+				SELECT sa.space_id FROM space_amenities sa
+				JOIN amenities a ON a.id = sa.amenity_id AND a.deleted_at IS NULL
+				WHERE sa.deleted_at IS NULL AND (a.uuid::text IN (?) OR a.name IN (?))
+				GROUP BY sa.space_id
+				HAVING COUNT(DISTINCT a.id) >= ?
+			)`, bun.In(qp.Amenities), bun.In(qp.Amenities), len(qp.Amenities))
+		}
+
+		for name, value := range qp.Attributes {
+			q = q.Where(`s.id IN (
+				SELECT sav.space_id FROM space_attributes sav
+				JOIN category_attributes ca ON ca.id = sav.attribute_id AND ca.deleted_at IS NULL
+				WHERE sav.deleted_at IS NULL AND ca.name = ? AND lower(sav.value) = lower(?)
+			)`, name, value)
+		}
+
+		return q
+	}
+}
+
+func spaceGeoFilter(qp QueryParams) (func(*bun.SelectQuery) *bun.SelectQuery, error) {
+	var near, bbox []float64
+
+	if qp.Near != "" {
+		var err error
+		if near, err = parseCoordinates(qp.Near, 2); err != nil {
+			return nil, fmt.Errorf("%w: near must be lat,lon", ErrInvalidCoordinates)
+		}
+
+		if err = validateCoordinates(&near[0], &near[1]); err != nil {
+			return nil, err
+		}
+
+		if qp.RadiusKm == 0 {
+			qp.RadiusKm = defaultSearchRadiusKm
+		}
+
+		if qp.RadiusKm < 0 || qp.RadiusKm > maxSearchRadiusKm {
+			return nil, fmt.Errorf("%w: radius_km must be between 0 and %d", ErrInvalidCoordinates, maxSearchRadiusKm)
+		}
+	}
+
+	if qp.BBox != "" {
+		var err error
+		if bbox, err = parseCoordinates(qp.BBox, 4); err != nil || bbox[0] > bbox[2] || bbox[1] > bbox[3] {
+			return nil, fmt.Errorf("%w: bbox must be min_lat,min_lon,max_lat,max_lon", ErrInvalidCoordinates)
+		}
+	}
+
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if near != nil {
+			lat, lon, meters := near[0], near[1], qp.RadiusKm*1000
+
+			q = q.Where("s.latitude IS NOT NULL").
+				Where("earth_box(ll_to_earth(?, ?), ?) @> ll_to_earth(s.latitude, s.longitude)", lat, lon, meters).
+				Where("earth_distance(ll_to_earth(?, ?), ll_to_earth(s.latitude, s.longitude)) <= ?", lat, lon, meters)
+		}
+
+		if bbox != nil {
+			q = q.Where("s.latitude IS NOT NULL").
+				Where("s.latitude BETWEEN ? AND ?", bbox[0], bbox[2]).
+				Where("s.longitude BETWEEN ? AND ?", bbox[1], bbox[3])
+		}
+
+		return q
+	}, nil
+}
+
+func spaceDistance(qp QueryParams) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		near, err := parseCoordinates(qp.Near, 2)
+		if qp.Near == "" || err != nil {
+			return q
+		}
+
+		q = q.ColumnExpr("round((earth_distance(ll_to_earth(?, ?), ll_to_earth(s.latitude, s.longitude)) / 1000)::numeric, 3) AS distance_km", near[0], near[1])
+
+		if qp.Sort == "" {
+			q = q.Order("distance_km ASC")
+		}
+
+		return q
+	}
+}
+
+func parseCoordinates(value string, n int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != n {
+		return nil, ErrInvalidCoordinates
+	}
+
+	coords := make([]float64, n)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, ErrInvalidCoordinates
+		}
+		coords[i] = v
+	}
+
+	return coords, nil
+}
+
+func validateCoordinates(lat, lon *float64) error {
+	if lat == nil && lon == nil {
+		return nil
+	}
+
+	if lat == nil || lon == nil {
+		return fmt.Errorf("%w: latitude and longitude must be set together", ErrInvalidCoordinates)
+	}
+
+	if math.IsNaN(*lat) || *lat < -90 || *lat > 90 {
+		return fmt.Errorf("%w: latitude must be between -90 and 90", ErrInvalidCoordinates)
+	}
+
+	if math.IsNaN(*lon) || *lon < -180 || *lon > 180 {
+		return fmt.Errorf("%w: longitude must be between -180 and 180", ErrInvalidCoordinates)
+	}
+
+	return nil
+}
+
+func authorizeSpace(ctx *gin.Context, spaceID int64) error {
+	var item Space
+	if err := db.NewSelect().Model(&item).Column("user_id", "organization_id").Where("id = ?", spaceID).Scan(ctx); err != nil {
+		return ErrForbidden
+	}
+
+	policy, _ := PolicyFor("space")
+	if !policy.Allows(ctx, "edit", item.UserID, item.OrganizationID) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func authorizeSpaceRecord(ctx *gin.Context, table, uuid string) error {
+	var spaceID int64
+	if err := db.NewSelect().Table(table).Column("space_id").Where("uuid = ?", uuid).Scan(ctx, &spaceID); err != nil {
+		return err
+	}
+
+	return authorizeSpace(ctx, spaceID)
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		value   string
+		n       int
+		want    []float64
+		wantErr bool
+	}{
+		{"14.5995,120.9842", 2, []float64{14.5995, 120.9842}, false},
+		{" -33.8688 , 151.2093 ", 2, []float64{-33.8688, 151.2093}, false},
+		{"14.5,120.9,14.7,121.1", 4, []float64{14.5, 120.9, 14.7, 121.1}, false},
+		{"14.5995", 2, nil, true},
+		{"14.5,120.9,1", 2, nil, true},
+		{"north,east", 2, nil, true},
+		{"NaN,120.9842", 2, nil, true},
+		{"14.5995,Inf", 2, nil, true},
+		{"", 2, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseCoordinates(tt.value, tt.n)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCoordinates(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseCoordinates(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseCoordinates(%q) = %v, want %v", tt.value, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		lat     *float64
+		lon     *float64
+		wantErr bool
+	}{
+		{"unset", nil, nil, false},
+		{"manila", f(14.5995), f(120.9842), false},
+		{"poles and antimeridian", f(-90), f(180), false},
+		{"latitude only", f(14.5995), nil, true},
+		{"longitude only", nil, f(120.9842), true},
+		{"latitude out of range", f(90.1), f(0), true},
+		{"longitude out of range", f(0), f(-180.1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCoordinates(tt.lat, tt.lon)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateCoordinates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidCoordinates) {
+				t.Errorf("validateCoordinates() error = %v, want ErrInvalidCoordinates", err)
+			}
+		})
+	}
+}
+
+func TestSpaceGeoFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		qp      QueryParams
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no geo params",
+			qp:   QueryParams{},
+		},
+		{
+			name: "near with default radius",
+			qp:   QueryParams{Near: "14.5995,120.9842"},
+			want: []string{
+				"s.latitude IS NOT NULL",
+				"earth_box(ll_to_earth(14.5995, 120.9842), 25000) @> ll_to_earth(s.latitude, s.longitude)",
+				"earth_distance(ll_to_earth(14.5995, 120.9842), ll_to_earth(s.latitude, s.longitude)) <= 25000",
+			},
+		},
+		{
+			name: "near with radius",
+			qp:   QueryParams{Near: "14.5995,120.9842", RadiusKm: 2.5},
+			want: []string{"<= 2500"},
+		},
+		{
+			name: "bbox",
+			qp:   QueryParams{BBox: "14.5,120.9,14.7,121.1"},
+			want: []string{
+				"s.latitude BETWEEN 14.5 AND 14.7",
+				"s.longitude BETWEEN 120.9 AND 121.1",
+			},
+		},
+		{name: "near missing longitude", qp: QueryParams{Near: "14.5995"}, wantErr: true},
+		{name: "near out of range", qp: QueryParams{Near: "95,120.9842"}, wantErr: true},
+		{name: "negative radius", qp: QueryParams{Near: "14.5995,120.9842", RadiusKm: -1}, wantErr: true},
+		{name: "radius too large", qp: QueryParams{Near: "14.5995,120.9842", RadiusKm: maxSearchRadiusKm + 1}, wantErr: true},
+		{name: "bbox inverted", qp: QueryParams{BBox: "14.7,120.9,14.5,121.1"}, wantErr: true},
+		{name: "bbox missing corner", qp: QueryParams{BBox: "14.5,120.9,14.7"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geo, err := spaceGeoFilter(tt.qp)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("spaceGeoFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				if !errors.Is(err, ErrInvalidCoordinates) {
+					t.Errorf("spaceGeoFilter() error = %v, want ErrInvalidCoordinates", err)
+				}
+				return
+			}
+
+			query := db.NewSelect().Model((*Space)(nil)).Column("s.id").Apply(geo).String()
+
+			if len(tt.want) == 0 && strings.Contains(query, "WHERE") {
+				t.Errorf("query should have no WHERE clause:\n%s", query)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(query, want) {
+					t.Errorf("query missing %q:\n%s", want, query)
+				}
+			}
+		})
+	}
+}
+
+func TestSpaceDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		qp        QueryParams
+		wantCol   bool
+		wantOrder bool
+	}{
+		{"no near", QueryParams{}, false, false},
+		{"near", QueryParams{Near: "14.5995,120.9842"}, true, true},
+		{"near with sort", QueryParams{Near: "14.5995,120.9842", Sort: "name"}, true, false},
+		{"invalid near", QueryParams{Near: "north,east"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := db.NewSelect().Model((*Space)(nil)).Column("s.id").Apply(spaceDistance(tt.qp)).String()
+
+			if got := strings.Contains(query, "AS distance_km"); got != tt.wantCol {
+				t.Errorf("distance column = %v, want %v:\n%s", got, tt.wantCol, query)
+			}
+
+			if got := strings.Contains(query, `ORDER BY "distance_km" ASC`); got != tt.wantOrder {
+				t.Errorf("distance ordering = %v, want %v:\n%s", got, tt.wantOrder, query)
+			}
+		})
+	}
+}
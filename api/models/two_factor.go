@@ -0,0 +1,248 @@
+package models
+
+import (
+	"api/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	RecoveryCode struct {
+		bun.BaseModel `bun:"table:recovery_codes,alias:rc"`
+
+		ID       int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID   int64     `bun:"user_id" json:"user_id"`
+		CodeHash string    `bun:"code_hash" json:"-"`
+		UsedAt   time.Time `bun:"used_at,nullzero,default:null" json:"used_at,omitzero"`
+
+		AppModel
+	}
+
+	TwoFactor struct{}
+)
+
+const (
+	totpIssuer        = "RENT TA"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorInvalidCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication has not been enrolled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+func (m TwoFactor) Enroll(ctx *gin.Context, userID int64) (secret, uri string, err error) {
+	var user User
+	if err = db.NewSelect().Model(&user).Where("id = ?", userID).Scan(ctx); err != nil {
+		return "", "", err
+	}
+
+	if !user.TOTPEnabledAt.IsZero() {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	if secret, err = utils.GenerateTOTPSecret(); err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().Model((*User)(nil)).
+			Set("totp_secret = ?", encrypted).
+			Set("totp_last_step = 0").
+			Set("updated_at = NOW()").
+			Where("id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+
+	go auditLog(ctx, nil, map[string]string{"two_factor": "enroll"}, userID, "auth", "2FA_ENROLL", err)
+	return secret, utils.TOTPURI(secret, account, totpIssuer), err
+}
+
+func (m TwoFactor) Confirm(ctx *gin.Context, userID int64, code string) (codes []string, err error) {
+	var user User
+	if err = db.NewSelect().Model(&user).Where("id = ?", userID).Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabledAt.IsZero() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, err := m.validateTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().Model((*User)(nil)).
+			Set("totp_enabled_at = NOW()").
+			Set("totp_last_step = ?", step).
+			Set("updated_at = NOW()").
+			Where("id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		codes, err = m.generateRecoveryCodes(ctx, trx, userID)
+		return err
+	})
+
+	go auditLog(ctx, nil, map[string]string{"two_factor": "enabled"}, userID, "auth", "2FA_CONFIRM", err)
+	return codes, err
+}
+
+func (m TwoFactor) RegenerateRecoveryCodes(ctx *gin.Context, userID int64, code string) (codes []string, err error) {
+	var user User
+	if err = db.NewSelect().Model(&user).Where("id = ?", userID).Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = m.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		codes, err = m.generateRecoveryCodes(ctx, trx, userID)
+		return err
+	})
+
+	go auditLog(ctx, nil, map[string]string{"two_factor": "recovery_codes"}, userID, "auth", "2FA_RECOVERY_CODES", err)
+	return codes, err
+}
+
+func (m TwoFactor) Disable(ctx *gin.Context, userID int64, code string) (err error) {
+	var user User
+	if err = db.NewSelect().Model(&user).Where("id = ?", userID).Scan(ctx); err != nil {
+		return err
+	}
+
+	if err = m.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().Model((*User)(nil)).
+			Set("totp_secret = NULL").
+			Set("totp_enabled_at = NULL").
+			Set("totp_last_step = 0").
+			Set("updated_at = NOW()").
+			Where("id = ?", userID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = trx.NewDelete().Model((*RecoveryCode)(nil)).Where("user_id = ?", userID).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, nil, map[string]string{"two_factor": "disabled"}, userID, "auth", "2FA_DISABLE", err)
+	return err
+}
+
+func (m TwoFactor) Verify(ctx *gin.Context, user User, code string) error {
+	if user.TOTPEnabledAt.IsZero() {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	if step, err := m.validateTOTP(user, code); err == nil {
+		res, err := db.NewUpdate().Model((*User)(nil)).
+			Set("totp_last_step = ?", step).
+			Where("id = ?", user.ID).
+			Where("totp_last_step < ?", step).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrTwoFactorInvalidCode
+		}
+
+		return nil
+	}
+
+	res, err := db.NewUpdate().Model((*RecoveryCode)(nil)).
+		Set("used_at = NOW()").
+		Set("updated_at = NOW()").
+		Where("user_id = ?", user.ID).
+		Where("code_hash = ?", utils.HashToken(normalizeRecoveryCode(code))).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTwoFactorInvalidCode
+	}
+
+	return nil
+}
+
+func (m TwoFactor) Required(ctx *gin.Context, userID int64) bool {
+	required, _ := utils.RequiresTwoFactor(ctx, userID)
+	return required
+}
+
+func (m TwoFactor) validateTOTP(user User, code string) (int64, error) {
+	if user.TOTPSecret == "" {
+		return 0, ErrTwoFactorNotEnrolled
+	}
+
+	secret, err := utils.DecryptSecret(user.TOTPSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return 0, ErrTwoFactorInvalidCode
+	}
+
+	return step, nil
+}
+
+func (m TwoFactor) generateRecoveryCodes(ctx *gin.Context, idb bun.IDB, userID int64) ([]string, error) {
+	if _, err := idb.NewDelete().Model((*RecoveryCode)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	items := make([]RecoveryCode, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		raw, err := utils.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		items = append(items, RecoveryCode{UserID: userID, CodeHash: utils.HashToken(normalizeRecoveryCode(code))})
+	}
+
+	_, err := idb.NewInsert().Model(&items).Exec(ctx)
+	return codes, err
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"api/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,9 +12,12 @@ type (
 	UserGroup struct {
 		bun.BaseModel `bun:"table:user_groups,alias:ug"`
 
-		ID      int64 `bun:"id,pk,autoincrement" json:"id"`
-		UserID  int64 `bun:"user_id" json:"user_id"`
-		GroupID int64 `bun:"group_id" json:"group_id"`
+		ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID     int64     `bun:"user_id" json:"user_id"`
+		GroupID    int64     `bun:"group_id" json:"group_id"`
+		ValidFrom  time.Time `bun:"valid_from,nullzero,default:null" json:"valid_from,omitzero"`
+		ValidUntil time.Time `bun:"valid_until,nullzero,default:null" json:"valid_until,omitzero"`
+		ExpiredAt  time.Time `bun:"expired_at,nullzero,default:null" json:"expired_at,omitzero"`
 
 		AppModel
 	}
@@ -23,7 +27,7 @@ func (m UserGroup) Upsert(ctx *gin.Context, item UserGroup) (int, UserGroup, err
 	var oldData *UserGroup
 	httpStatus, action := 201, "POST"
 
-	var setClauseColumns = []string{"user_id", "group_id", "updated_at"}
+	var setClauseColumns = []string{"user_id", "group_id", "valid_from", "valid_until", "updated_at"}
 
 	if item.UUID != "" {
 		var tmp UserGroup
@@ -32,19 +36,42 @@ func (m UserGroup) Upsert(ctx *gin.Context, item UserGroup) (int, UserGroup, err
 		}
 	}
 
+	if err := validateValidity(item.ValidFrom, item.ValidUntil); err != nil {
+		return 400, item, err
+	}
+
+	item.ExpiredAt = time.Time{}
+	if oldData != nil {
+		item.ExpiredAt = oldData.ExpiredAt
+	}
+
 	setClause := parseSetClause(setClauseColumns)
 	err := executeTransaction(ctx, func(trx *bun.Tx) error {
 		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "user_group", action, err)
 	return httpStatus, item, err
 }
 
 func (m UserGroup) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{}
-	var allowedSortFields = map[string]bool{}
+	var allowedSortFields = map[string]bool{"valid_from": true, "valid_until": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"user_id":     "number",
+		"group_id":    "number",
+		"valid_from":  "date",
+		"valid_until": "date",
+		"expired_at":  "date",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +85,7 @@ func (m UserGroup) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []UserGroup
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data).Apply(validityScope(qp.Validity)), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +98,10 @@ func (m UserGroup) Read(qp QueryParams) (res Results, err error) {
 func (m UserGroup) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "user_groups", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "user_group", "DELETE", err)
 	return
 }
@@ -78,6 +109,10 @@ func (m UserGroup) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, m
 func (m UserGroup) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "user_groups", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "user_group", "PATCH", err)
 	return
 }
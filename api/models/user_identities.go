@@ -0,0 +1,202 @@
+package models
+
+import (
+	"api/utils"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
+)
+
+type (
+	UserIdentity struct {
+		bun.BaseModel `bun:"table:user_identities,alias:ui"`
+
+		ID        int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID    int64     `bun:"user_id" json:"user_id"`
+		Provider  string    `bun:"provider" json:"provider"`
+		Subject   string    `bun:"subject" json:"subject"`
+		Email     string    `bun:"email" json:"email"`
+		LastLogin time.Time `bun:"last_login,nullzero,default:null" json:"last_login,omitzero"`
+
+		AppModel
+	}
+)
+
+var (
+	ErrIdentityEmailNotVerified = errors.New("the identity provider has not verified this email address")
+	ErrIdentitySignupDisabled   = errors.New("no account is linked to this identity and sign-up is disabled")
+	ErrIdentityLinkRequired     = errors.New("an unverified account already uses this email, log in and link this identity from your account")
+	ErrIdentityLinked           = errors.New("this identity is already linked to another account")
+)
+
+var usernameSanitizer = regexp.MustCompile(`[^a-z0-9_.]+`)
+
+func (m UserIdentity) Resolve(ctx *gin.Context, provider string, claims *utils.OIDCClaims, allowSignup bool) (user User, err error) {
+	var identity UserIdentity
+	err = db.NewSelect().Model(&identity).
+		Where("provider = ?", provider).
+		Where("subject = ?", claims.Subject).
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+
+	if err == nil {
+		if err = db.NewSelect().Model(&user).Where("id = ?", identity.UserID).Scan(ctx); err != nil {
+			return user, err
+		}
+
+		_, err = db.NewUpdate().Model((*UserIdentity)(nil)).
+			Set("last_login = NOW()").
+			Set("email = ?", claims.Email).
+			Where("id = ?", identity.ID).
+			Exec(ctx)
+
+		return user, err
+	}
+
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return user, ErrIdentityEmailNotVerified
+	}
+
+	action := "LINK"
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		err := trx.NewSelect().Model(&user).
+			Where("lower(email) = lower(?)", claims.Email).
+			Where("deleted_at IS NULL").
+			Scan(ctx)
+
+		if err == nil && user.EmailVerifiedAt.IsZero() {
+			return ErrIdentityLinkRequired
+		}
+
+		if err != nil {
+			if !allowSignup {
+				return ErrIdentitySignupDisabled
+			}
+
+			action = "SIGNUP"
+			if user, err = m.createUser(ctx, trx, claims); err != nil {
+				return err
+			}
+		}
+
+		identity = UserIdentity{
+			UserID:    user.ID,
+			Provider:  provider,
+			Subject:   claims.Subject,
+			Email:     claims.Email,
+			LastLogin: time.Now(),
+		}
+
+		_, err = trx.NewInsert().Model(&identity).Exec(ctx)
+		return err
+	})
+
+	if user.ID != 0 {
+		ctx.Set("userId", int(user.ID))
+		go auditLog(ctx, nil, identity, identity.ID, "auth", "OIDC_"+action, err)
+	}
+
+	return user, err
+}
+
+func (m UserIdentity) Link(ctx *gin.Context, provider string, claims *utils.OIDCClaims, userUUID string) (identity UserIdentity, err error) {
+	var user User
+	if err = db.NewSelect().Model(&user).Where("uuid = ?", userUUID).Where("deleted_at IS NULL").Scan(ctx); err != nil {
+		return identity, err
+	}
+
+	err = db.NewSelect().Model(&identity).
+		Where("provider = ?", provider).
+		Where("subject = ?", claims.Subject).
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+
+	if err == nil {
+		if identity.UserID != user.ID {
+			return identity, ErrIdentityLinked
+		}
+
+		return identity, nil
+	}
+
+	identity = UserIdentity{
+		UserID:    user.ID,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		LastLogin: time.Now(),
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewInsert().Model(&identity).Exec(ctx)
+		return err
+	})
+
+	go auditLog(ctx, nil, identity, identity.ID, "auth", "OIDC_LINK", err)
+	return identity, err
+}
+
+func (m UserIdentity) createUser(ctx *gin.Context, idb bun.IDB, claims *utils.OIDCClaims) (user User, err error) {
+	username, err := m.availableUsername(ctx, idb, claims)
+	if err != nil {
+		return user, err
+	}
+
+	user = User{
+		Username:        username,
+		Email:           claims.Email,
+		EmailVerifiedAt: time.Now(),
+	}
+
+	if claims.GivenName != "" {
+		user.FirstName = &claims.GivenName
+	}
+
+	if claims.FamilyName != "" {
+		user.LastName = &claims.FamilyName
+	}
+
+	_, err = idb.NewInsert().Model(&user).Exec(ctx)
+	return user, err
+}
+
+func (m UserIdentity) availableUsername(ctx *gin.Context, idb bun.IDB, claims *utils.OIDCClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	base = usernameSanitizer.ReplaceAllString(strings.ToLower(base), "")
+	if base == "" {
+		base = "user"
+	}
+
+	if len(base) > 36 {
+		base = base[:36]
+	}
+
+	username := base
+	for range 5 {
+		exists, err := idb.NewSelect().Model((*User)(nil)).Where("username = ?", username).Exists(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			return username, nil
+		}
+
+		suffix, err := utils.RandomToken(4)
+		if err != nil {
+			return "", err
+		}
+
+		username = base + "_" + suffix
+	}
+
+	return username, nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"api/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,9 +12,12 @@ type (
 	UserRole struct {
 		bun.BaseModel `bun:"table:user_roles,alias:ur"`
 
-		ID     int64 `bun:"id,pk,autoincrement" json:"id"`
-		UserID int64 `bun:"user_id" json:"user_id"`
-		RoleID int64 `bun:"role_id" json:"role_id"`
+		ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+		UserID     int64     `bun:"user_id" json:"user_id"`
+		RoleID     int64     `bun:"role_id" json:"role_id"`
+		ValidFrom  time.Time `bun:"valid_from,nullzero,default:null" json:"valid_from,omitzero"`
+		ValidUntil time.Time `bun:"valid_until,nullzero,default:null" json:"valid_until,omitzero"`
+		ExpiredAt  time.Time `bun:"expired_at,nullzero,default:null" json:"expired_at,omitzero"`
 
 		AppModel
 	}
@@ -23,7 +27,7 @@ func (m UserRole) Upsert(ctx *gin.Context, item UserRole) (int, UserRole, error)
 	var oldData *UserRole
 	httpStatus, action := 201, "POST"
 
-	var setClauseColumns = []string{"user_id", "role_id", "updated_at"}
+	var setClauseColumns = []string{"user_id", "role_id", "valid_from", "valid_until", "updated_at"}
 
 	if item.UUID != "" {
 		var tmp UserRole
@@ -32,19 +36,42 @@ func (m UserRole) Upsert(ctx *gin.Context, item UserRole) (int, UserRole, error)
 		}
 	}
 
+	if err := validateValidity(item.ValidFrom, item.ValidUntil); err != nil {
+		return 400, item, err
+	}
+
+	item.ExpiredAt = time.Time{}
+	if oldData != nil {
+		item.ExpiredAt = oldData.ExpiredAt
+	}
+
 	setClause := parseSetClause(setClauseColumns)
 	err := executeTransaction(ctx, func(trx *bun.Tx) error {
 		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
 		return err
 	})
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, oldData, item, item.ID, "user_role", action, err)
 	return httpStatus, item, err
 }
 
 func (m UserRole) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{}
-	var allowedSortFields = map[string]bool{}
+	var allowedSortFields = map[string]bool{"valid_from": true, "valid_until": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"user_id":     "number",
+		"role_id":     "number",
+		"valid_from":  "date",
+		"valid_until": "date",
+		"expired_at":  "date",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
 
 	q := db.NewSelect()
 
@@ -58,7 +85,7 @@ func (m UserRole) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []UserRole
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data).Apply(validityScope(qp.Validity)), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -71,6 +98,10 @@ func (m UserRole) Read(qp QueryParams) (res Results, err error) {
 func (m UserRole) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "user_roles", uuid, "deleted_at")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"deleted_at": deletedAt.String()}, id, "user_role", "DELETE", err)
 	return
 }
@@ -78,6 +109,10 @@ func (m UserRole) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, ms
 func (m UserRole) UpdateStatus(ctx *gin.Context, uuid string) (status, msg string, err error) {
 	id, _, status, msg, err := setStatus(ctx, "user_roles", uuid, "status")
 
+	if err == nil {
+		utils.InvalidatePermissions()
+	}
+
 	go auditLog(ctx, nil, map[string]string{"status": status}, id, "user_role", "PATCH", err)
 	return
 }
@@ -2,6 +2,8 @@ package models
 
 import (
 	"api/utils"
+	"errors"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -27,11 +29,30 @@ type (
 		IsAdmin    bool            `bun:"is_admin" json:"is_admin"`
 		IsOnline   bool            `bun:"is_online" json:"is_online"`
 
+		EmailVerifiedAt time.Time `bun:"email_verified_at,nullzero,default:null" json:"email_verified_at,omitzero"`
+		TOTPSecret      string    `bun:"totp_secret,nullzero,default:null" json:"-"`
+		TOTPEnabledAt   time.Time `bun:"totp_enabled_at,nullzero,default:null" json:"two_factor_enabled_at,omitzero"`
+		TOTPLastStep    int64     `bun:"totp_last_step,default:0" json:"-"`
+
+		FailedLoginCount int       `bun:"failed_login_count,default:0" json:"-"`
+		LastFailedLogin  time.Time `bun:"last_failed_login,nullzero,default:null" json:"-"`
+		LockedUntil      time.Time `bun:"locked_until,nullzero,default:null" json:"-"`
+
 		Permissions []string `bun:"-" json:"permissions,omitempty"`
 		AppModel
 	}
+
+	PermissionExplanation struct {
+		UserUUID    string                   `json:"user_uuid"`
+		UserStatus  string                   `json:"user_status"`
+		IsAdmin     bool                     `json:"is_admin"`
+		Permissions []string                 `json:"permissions"`
+		Sources     []utils.PermissionSource `json:"sources"`
+	}
 )
 
+var ErrImpersonateSelf = errors.New("you cannot impersonate yourself")
+
 func (m User) Upsert(ctx *gin.Context, item User) (int, User, error) {
 	var oldData *User
 	httpStatus, action := 201, "POST"
@@ -57,6 +78,10 @@ func (m User) Upsert(ctx *gin.Context, item User) (int, User, error) {
 		}
 	}
 
+	if oldData == nil && item.EmailVerifiedAt.IsZero() {
+		item.EmailVerifiedAt = time.Now()
+	}
+
 	setClause := parseSetClause(setClauseColumns)
 	err := executeTransaction(ctx, func(trx *bun.Tx) error {
 		_, err := trx.NewInsert().Model(&item).On("CONFLICT (uuid) DO UPDATE").Set(setClause).Exec(ctx)
@@ -70,21 +95,32 @@ func (m User) Upsert(ctx *gin.Context, item User) (int, User, error) {
 func (m User) Read(qp QueryParams) (res Results, err error) {
 	var coalesceCols = []string{"username", "first_name", "middle_name", "last_name"}
 	var allowedSortFields = map[string]bool{"username": true, "email": true}
+	var allowedFilterFields = map[string]string{
+		"id":          "number",
+		"username":    "text",
+		"email":       "text",
+		"first_name":  "text",
+		"middle_name": "text",
+		"last_name":   "text",
+		"mobile":      "text",
+		"optin":       "bool",
+		"last_login":  "date",
+		"is_online":   "bool",
+		"created_at":  "date",
+		"updated_at":  "date",
+	}
 
 	q := db.NewSelect()
 
 	if qp.UUID != "all" {
-		var userPerm struct {
-			User
-			Permissions []string `bun:"permissions" json:"permissions"`
+		var item User
+		if err = q.Model(&item).Where("u.uuid = ?", qp.UUID).Scan(qp.Ctx); err != nil {
+			return res, err
 		}
 
-		utils.GetPermissions(func(sq *bun.SelectQuery) *bun.SelectQuery {
-			return sq.Where("u.uuid = ?", qp.UUID)
-		}, qp.Ctx, &userPerm)
-
-		item := userPerm.User
-		item.Permissions = userPerm.Permissions
+		if item.Status == "O" && item.DeletedAt.IsZero() {
+			item.Permissions, err = utils.UserPermissions(qp.Ctx, item.ID)
+		}
 
 		res.Item = item
 
@@ -92,7 +128,7 @@ func (m User) Read(qp QueryParams) (res Results, err error) {
 	}
 
 	var data []User
-	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields)
+	q = sanitizeQuery(q.Model(&data), qp, coalesceCols, allowedSortFields, allowedFilterFields)
 	res.Count, err = q.ScanAndCount(qp.Ctx)
 
 	for _, item := range data {
@@ -102,6 +138,153 @@ func (m User) Read(qp QueryParams) (res Results, err error) {
 	return res, err
 }
 
+func (m User) WithPermissions(ctx *gin.Context, fn func(*bun.SelectQuery) *bun.SelectQuery) (item User, err error) {
+	err = db.NewSelect().Model(&item).
+		Where("u.status = 'O'").
+		Where("u.deleted_at IS NULL").
+		Apply(fn).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
+	item.Permissions, err = utils.UserPermissions(ctx, item.ID)
+	return item, err
+}
+
+func (m User) ExplainPermissions(ctx *gin.Context, uuid string) (res PermissionExplanation, err error) {
+	var item User
+	if err = db.NewSelect().Model(&item).Where("uuid = ?", uuid).Scan(ctx); err != nil {
+		return res, err
+	}
+
+	res = PermissionExplanation{
+		UserUUID:    item.UUID,
+		UserStatus:  item.Status,
+		IsAdmin:     item.IsAdmin,
+		Permissions: []string{},
+	}
+
+	if !item.DeletedAt.IsZero() {
+		res.UserStatus = "D"
+	}
+
+	if res.Sources, err = utils.ExplainPermissions(ctx, item.ID); err != nil {
+		return res, err
+	}
+
+	for _, source := range res.Sources {
+		if source.Effective && res.UserStatus == "O" && !slices.Contains(res.Permissions, source.Permission) {
+			res.Permissions = append(res.Permissions, source.Permission)
+		}
+	}
+
+	return res, nil
+}
+
+func (m User) SetPassword(ctx *gin.Context, uuid, password string) (err error) {
+	var item User
+
+	hashed, err := utils.HandlePassword("hash", password, "")
+	if err != nil {
+		return err
+	}
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().
+			Model(&item).
+			Set("password = ?", hashed).
+			Set("updated_at = NOW()").
+			Where("uuid = ?", uuid).
+			Where("deleted_at IS NULL").
+			Returning("id").
+			Exec(ctx)
+		if err != nil || item.ID == 0 {
+			return err
+		}
+
+		return revokeUserSessions(ctx, trx, item.ID, ctx.GetString("sessionId"))
+	})
+
+	if err == nil && item.ID == 0 {
+		err = errors.New("user not found")
+	}
+
+	go auditLog(ctx, nil, map[string]string{"password": "updated", "sessions": "revoked"}, item.ID, "user", "PUT", err)
+	return err
+}
+
+func (m User) ChangePassword(ctx *gin.Context, id int64, currentPassword, newPassword string) (err error) {
+	var item User
+	if err = db.NewSelect().Model(&item).Where("id = ?", id).Where("deleted_at IS NULL").Scan(ctx); err != nil {
+		return err
+	}
+
+	if item.Password == "" {
+		if err = requireRecentLogin(ctx); err != nil {
+			return err
+		}
+	} else if _, err = utils.HandlePassword("check", currentPassword, item.Password); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	return m.SetPassword(ctx, item.UUID, newPassword)
+}
+
+func (m User) Unlock(ctx *gin.Context, uuid string) (err error) {
+	var item User
+
+	err = executeTransaction(ctx, func(trx *bun.Tx) error {
+		_, err := trx.NewUpdate().
+			Model(&item).
+			Set("failed_login_count = 0").
+			Set("locked_until = NULL").
+			Set("updated_at = NOW()").
+			Where("uuid = ?", uuid).
+			Where("deleted_at IS NULL").
+			Returning("id").
+			Exec(ctx)
+		return err
+	})
+
+	if err == nil && item.ID == 0 {
+		err = errors.New("user not found")
+	}
+
+	go auditLog(ctx, nil, map[string]string{"locked_until": "cleared"}, item.ID, "user", "UNLOCK", err)
+	return err
+}
+
+func (m User) Impersonate(ctx *gin.Context, uuid string) (tokens *utils.Tokens, err error) {
+	if !ctx.GetBool("isAdmin") || ctx.GetString("apiKeyPrefix") != "" {
+		return nil, ErrForbidden
+	}
+
+	var item User
+	err = db.NewSelect().Model(&item).
+		Where("uuid = ?", uuid).
+		Where("status = 'O'").
+		Where("deleted_at IS NULL").
+		Scan(ctx)
+
+	if err == nil && item.ID == int64(ctx.GetInt("userId")) {
+		err = ErrImpersonateSelf
+	}
+
+	if err == nil {
+		tokens, err = utils.GenerateImpersonationJWT(item.UUID, item.Username, ctx.GetString("sessionId"), ctx.GetString("userUUID"))
+	}
+
+	after := map[string]string{"impersonated": uuid}
+	if tokens != nil {
+		after["expires_at"] = tokens.ExpiresAt.String()
+	}
+
+	go auditLog(ctx, nil, after, item.ID, "user", "IMPERSONATE", err)
+	return tokens, err
+}
+
 func (m User) Delete(ctx *gin.Context, uuid string) (deletedAt time.Time, msg string, err error) {
 	id, deletedAt, _, msg, err := setStatus(ctx, "users", uuid, "deleted_at")
 
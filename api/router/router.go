@@ -34,6 +34,33 @@ func InitRouters(router *gin.Engine) {
 	var group_permission = controllers.GroupPermissionController{}
 	group_permission.InitGroupPermissionController(router)
 
+	var api_key = controllers.APIKeyController{}
+	api_key.InitAPIKeyController(router)
+
+	var organization = controllers.OrganizationController{}
+	organization.InitOrganizationController(router)
+
 	var space = controllers.SpaceController{}
 	space.InitSpaceController(router)
+
+	var category = controllers.CategoryController{}
+	category.InitCategoryController(router)
+
+	var amenity = controllers.AmenityController{}
+	amenity.InitAmenityController(router)
+
+	var category_attribute = controllers.CategoryAttributeController{}
+	category_attribute.InitCategoryAttributeController(router)
+
+	var space_category = controllers.SpaceCategoryController{}
+	space_category.InitSpaceCategoryController(router)
+
+	var space_amenity = controllers.SpaceAmenityController{}
+	space_amenity.InitSpaceAmenityController(router)
+
+	var space_attribute = controllers.SpaceAttributeController{}
+	space_attribute.InitSpaceAttributeController(router)
+
+	var space_media = controllers.SpaceMediaController{}
+	space_media.InitSpaceMediaController(router)
 }
@@ -1 +1,3 @@
-CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
\ No newline at end of file
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS cube;
+CREATE EXTENSION IF NOT EXISTS earthdistance;
\ No newline at end of file
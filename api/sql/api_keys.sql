@@ -0,0 +1,28 @@
+-- API Keys table
+CREATE TABLE IF NOT EXISTS api_keys (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  organization_id bigint references organizations(id) on delete cascade,
+  name varchar(255) not null,
+  prefix varchar(16) not null unique,
+  key_hash varchar(64) not null,
+  permissions jsonb not null default '[]',
+  expires_at timestamptz,
+  last_used_at timestamptz,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id ON api_keys(user_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS api_keys_organization_id ON api_keys(organization_id) WHERE deleted_at IS NULL;
+
+-- Upgrade existing installations
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id bigint references organizations(id) on delete cascade;
@@ -1,7 +1,8 @@
 -- Audit Logs table
 CREATE TABLE IF NOT EXISTS audit_logs (
   id bigserial primary key,
-  user_id bigint not null references users(id),
+  user_id bigint references users(id),
+  impersonator_id bigint references users(id),
   token text,
   path varchar(250),
   action varchar(150),
@@ -14,4 +15,8 @@ CREATE TABLE IF NOT EXISTS audit_logs (
   ip_address inet,
   user_agent text,
   created_at timestamptz not null default now()
-);
\ No newline at end of file
+);
+
+-- Upgrade existing installations
+ALTER TABLE audit_logs ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS impersonator_id bigint references users(id);
@@ -0,0 +1,22 @@
+-- Categories table
+CREATE TABLE IF NOT EXISTS categories (
+  id bigserial primary key,
+  name varchar(100) not null unique,
+  description text,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+INSERT INTO categories (name, description) VALUES
+  ('warehouse_storage', 'Warehouse and storage space'),
+  ('open_gym', 'Open gym and fitness space'),
+  ('covered_court', 'Covered sports court')
+ON CONFLICT (name) DO NOTHING;
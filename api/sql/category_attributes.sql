@@ -0,0 +1,23 @@
+-- Category Attributes table
+CREATE TABLE IF NOT EXISTS category_attributes (
+  id bigserial primary key,
+  category_id bigint not null references categories(id) on delete cascade,
+  name varchar(100) not null,
+  description text,
+  data_type varchar(20) not null default 'text' CHECK (data_type IN ('text', 'number', 'boolean', 'option')),
+  options jsonb not null default '[]',
+  required boolean not null default false,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_category_attribute_active ON category_attributes(category_id, name)
+WHERE deleted_at IS NULL;
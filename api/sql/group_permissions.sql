@@ -16,5 +16,5 @@ CREATE TABLE IF NOT EXISTS group_permissions (
   CONSTRAINT unique_group_permission UNIQUE (group_id, permission_id, deleted_at)
 );
 
-CREATE UNIQUE INDEX unique_group_permission_active ON group_permissions(group_id, permission_id)
+CREATE UNIQUE INDEX IF NOT EXISTS unique_group_permission_active ON group_permissions(group_id, permission_id)
 WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- JWT Keys table
+CREATE TABLE IF NOT EXISTS jwt_keys (
+  id bigserial primary key,
+  kid varchar(64) not null unique,
+  algorithm varchar(16) not null,
+  private_key text not null,
+  public_key text not null,
+  created_at timestamptz not null default now(),
+  retired_at timestamptz,
+  expires_at timestamptz
+);
@@ -0,0 +1,14 @@
+-- Login Attempts table
+CREATE TABLE IF NOT EXISTS login_attempts (
+  id bigserial primary key,
+  user_id bigint references users(id) on delete cascade,
+  username varchar(255),
+  ip_address inet,
+  user_agent text,
+  success boolean not null default false,
+  reason varchar(100),
+  created_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_ip_address ON login_attempts(ip_address, created_at) WHERE success = false;
+CREATE INDEX IF NOT EXISTS login_attempts_user_id ON login_attempts(user_id, created_at);
@@ -0,0 +1,27 @@
+-- Organization Members table
+CREATE TABLE IF NOT EXISTS organization_members (
+  id bigserial primary key,
+  organization_id bigint not null references organizations(id) on delete cascade,
+  user_id bigint references users(id) on delete cascade,
+  role_id bigint not null references roles(id),
+  email varchar(255) not null,
+  token_hash varchar(64) unique,
+  invited_by bigint references users(id) on delete set null,
+  invite_expires_at timestamptz,
+  accepted_at timestamptz,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_organization_member_active ON organization_members(organization_id, user_id)
+WHERE deleted_at IS NULL AND user_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS organization_members_user_id ON organization_members(user_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,45 @@
+-- Organizations table
+CREATE TABLE IF NOT EXISTS organizations (
+  id bigserial primary key,
+  name varchar(255) not null,
+  description text,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+-- Organization-scoped roles
+INSERT INTO roles (name, description, scope) VALUES
+  ('org_owner', 'Organization owner', 'organization'),
+  ('org_manager', 'Organization manager', 'organization'),
+  ('org_front_desk', 'Organization front desk', 'organization')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+  ('organization:manage', 'Manage an organization and its members'),
+  ('organization:read', 'View an organization and its members'),
+  ('space:manage', 'Manage spaces'),
+  ('space:edit', 'Edit spaces'),
+  ('space:read', 'View spaces'),
+  ('space:update_status', 'Archive or restore spaces')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r
+JOIN permissions p ON (r.name, p.name) IN (
+  ('org_owner', 'organization:manage'),
+  ('org_owner', 'space:manage'),
+  ('org_manager', 'organization:read'),
+  ('org_manager', 'space:edit'),
+  ('org_manager', 'space:update_status'),
+  ('org_front_desk', 'organization:read'),
+  ('org_front_desk', 'space:read')
+)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,22 @@
+-- Password Resets table
+CREATE TABLE IF NOT EXISTS password_resets (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  token_hash varchar(64) not null unique,
+  expires_at timestamptz not null,
+  used_at timestamptz,
+  ip_address inet,
+  user_agent text,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE INDEX IF NOT EXISTS password_resets_user_id ON password_resets(user_id) WHERE used_at IS NULL;
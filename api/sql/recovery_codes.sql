@@ -0,0 +1,19 @@
+-- Recovery Codes table
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  code_hash varchar(64) not null,
+  used_at timestamptz,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE INDEX IF NOT EXISTS recovery_codes_user_id ON recovery_codes(user_id) WHERE used_at IS NULL;
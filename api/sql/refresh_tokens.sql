@@ -0,0 +1,25 @@
+-- Refresh Tokens table
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  family_id uuid not null references sessions(uuid) on delete cascade,
+  token_hash varchar(64) not null unique,
+  expires_at timestamptz not null,
+  revoked_at timestamptz,
+  replaced_by bigint references refresh_tokens(id) on delete set null,
+  ip_address inet,
+  user_agent text,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id ON refresh_tokens(user_id) WHERE revoked_at IS NULL;
@@ -16,5 +16,5 @@ CREATE TABLE IF NOT EXISTS role_permissions (
   CONSTRAINT unique_role_permission UNIQUE (role_id, permission_id, deleted_at)
 );
 
-CREATE UNIQUE INDEX unique_role_permission_active ON role_permissions(role_id, permission_id)
+CREATE UNIQUE INDEX IF NOT EXISTS unique_role_permission_active ON role_permissions(role_id, permission_id)
 WHERE deleted_at IS NULL;
\ No newline at end of file
@@ -1,8 +1,11 @@
 -- Roles table
 CREATE TABLE IF NOT EXISTS roles (
   id bigserial primary key,
+  parent_role_id bigint references roles(id) on delete set null,
   name varchar(255) not null unique,
   description text,
+  require_2fa boolean not null default false,
+  scope varchar(20) not null default 'global',
   active boolean not null default true,
   status varchar(1) not null default 'O',
   flag varchar(45),
@@ -13,4 +16,9 @@ CREATE TABLE IF NOT EXISTS roles (
   updated_by bigint default 0,
   deleted_at timestamptz,
   deleted_by bigint default 0
-);
\ No newline at end of file
+);
+
+-- Upgrade existing installations
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS require_2fa boolean not null default false;
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS parent_role_id bigint references roles(id) on delete set null;
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS scope varchar(20) not null default 'global';
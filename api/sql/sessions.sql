@@ -0,0 +1,23 @@
+-- Sessions table
+CREATE TABLE IF NOT EXISTS sessions (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  device varchar(255),
+  ip_address inet,
+  user_agent text,
+  last_seen_at timestamptz not null default now(),
+  expires_at timestamptz not null,
+  revoked_at timestamptz,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id ON sessions(user_id) WHERE revoked_at IS NULL;
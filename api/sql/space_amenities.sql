@@ -0,0 +1,21 @@
+-- Space Amenities table
+CREATE TABLE IF NOT EXISTS space_amenities (
+  id bigserial primary key,
+  space_id bigint not null references spaces(id) on delete cascade,
+  amenity_id bigint not null references amenities(id) on delete cascade,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_space_amenity_active ON space_amenities(space_id, amenity_id)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS space_amenities_amenity_id ON space_amenities(amenity_id) WHERE deleted_at IS NULL;
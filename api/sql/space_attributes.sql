@@ -0,0 +1,22 @@
+-- Space Attributes table
+CREATE TABLE IF NOT EXISTS space_attributes (
+  id bigserial primary key,
+  space_id bigint not null references spaces(id) on delete cascade,
+  attribute_id bigint not null references category_attributes(id) on delete cascade,
+  value text not null,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_space_attribute_active ON space_attributes(space_id, attribute_id)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS space_attributes_attribute_value ON space_attributes(attribute_id, value) WHERE deleted_at IS NULL;
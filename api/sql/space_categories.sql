@@ -0,0 +1,21 @@
+-- Space Categories table
+CREATE TABLE IF NOT EXISTS space_categories (
+  id bigserial primary key,
+  space_id bigint not null references spaces(id) on delete cascade,
+  category_id bigint not null references categories(id) on delete cascade,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_space_category_active ON space_categories(space_id, category_id)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS space_categories_category_id ON space_categories(category_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,29 @@
+-- Space Media table
+CREATE TABLE IF NOT EXISTS space_media (
+  id bigserial primary key,
+  space_id bigint not null references spaces(id) on delete cascade,
+  storage_key text not null,
+  thumbnail_key text not null,
+  webp_key text not null,
+  content_type varchar(45) not null,
+  size_bytes bigint not null default 0,
+  width int not null default 0,
+  height int not null default 0,
+  position int not null default 0,
+  is_cover boolean not null default false,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_space_media_cover ON space_media(space_id)
+WHERE is_cover AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS space_media_position ON space_media(space_id, position) WHERE deleted_at IS NULL;
@@ -2,15 +2,23 @@
 CREATE TABLE IF NOT EXISTS spaces (
   id bigserial primary key,
   user_id bigint not null references users(id) on delete cascade,
+  organization_id bigint references organizations(id) on delete set null,
   name varchar(45),
   description text,
   address jsonb,
+  latitude double precision CHECK (latitude BETWEEN -90 AND 90),
+  longitude double precision CHECK (longitude BETWEEN -180 AND 180),
   price_per_hour numeric(11,2) default 0,
   price_per_day numeric(11,2) default 0,
   price_per_month numeric(11,2) default 0,
   size numeric(11,2) default 0,
   capacity bigint default 0,
   availability varchar(1) not null default 'A',
+  search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(address->>'city', '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'C')
+  ) STORED,
   active boolean not null default true,
   status varchar(1) not null default 'O',
   flag varchar(45),
@@ -20,5 +28,31 @@ CREATE TABLE IF NOT EXISTS spaces (
   updated_at timestamptz not null default now(),
   updated_by bigint default 0,
   deleted_at timestamptz,
-  deleted_by bigint default 0
-);
\ No newline at end of file
+  deleted_by bigint default 0,
+  CONSTRAINT spaces_coordinates CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+
+-- Upgrade existing installations
+ALTER TABLE spaces ADD COLUMN IF NOT EXISTS organization_id bigint references organizations(id) on delete set null;
+ALTER TABLE spaces ADD COLUMN IF NOT EXISTS latitude double precision CHECK (latitude BETWEEN -90 AND 90);
+ALTER TABLE spaces ADD COLUMN IF NOT EXISTS longitude double precision CHECK (longitude BETWEEN -180 AND 180);
+
+DO $$
+BEGIN
+  ALTER TABLE spaces ADD CONSTRAINT spaces_coordinates CHECK ((latitude IS NULL) = (longitude IS NULL));
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS spaces_location ON spaces USING gist (ll_to_earth(latitude, longitude))
+WHERE latitude IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS spaces_coordinates_bbox ON spaces(latitude, longitude)
+WHERE latitude IS NOT NULL;
+
+ALTER TABLE spaces ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+  setweight(to_tsvector('english', coalesce(address->>'city', '')), 'B') ||
+  setweight(to_tsvector('english', coalesce(description, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS spaces_search_vector ON spaces USING gin (search_vector);
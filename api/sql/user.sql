@@ -11,9 +11,16 @@ CREATE TABLE IF NOT EXISTS users (
   address jsonb,
   optin boolean not null default false,
   last_login timestamptz,
+  failed_login_count int not null default 0,
+  last_failed_login timestamptz,
+  locked_until timestamptz,
   metadata jsonb,
   is_admin boolean not null default false,
   is_online boolean not null default false,
+  email_verified_at timestamptz,
+  totp_secret text,
+  totp_enabled_at timestamptz,
+  totp_last_step bigint not null default 0,
   active boolean not null default true,
   status varchar(1) not null default 'O',
   flag varchar(45),
@@ -24,4 +31,20 @@ CREATE TABLE IF NOT EXISTS users (
   updated_by bigint default 0,
   deleted_at timestamptz,
   deleted_by bigint default 0
-);
\ No newline at end of file
+);
+
+-- Upgrade existing installations
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'email_verified_at') THEN
+    ALTER TABLE users ADD COLUMN email_verified_at timestamptz;
+    UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+  END IF;
+END $$;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint not null default 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_count int not null default 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_failed_login timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
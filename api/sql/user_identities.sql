@@ -0,0 +1,22 @@
+-- User Identities table
+CREATE TABLE IF NOT EXISTS user_identities (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  provider varchar(100) not null,
+  subject varchar(255) not null,
+  email varchar(255),
+  last_login timestamptz,
+  active boolean not null default true,
+  status varchar(1) not null default 'O',
+  flag varchar(45),
+  uuid uuid not null default gen_random_uuid() unique,
+  created_at timestamptz not null default now(),
+  created_by bigint default 0,
+  updated_at timestamptz not null default now(),
+  updated_by bigint default 0,
+  deleted_at timestamptz,
+  deleted_by bigint default 0,
+  CONSTRAINT unique_user_identity UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_user_id ON user_identities(user_id);
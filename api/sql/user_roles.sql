@@ -3,6 +3,9 @@ CREATE TABLE IF NOT EXISTS user_roles (
   id bigserial primary key,
   user_id bigint not null references users(id) on delete cascade,
   role_id bigint not null references roles(id) on delete cascade,
+  valid_from timestamptz,
+  valid_until timestamptz,
+  expired_at timestamptz,
   active boolean not null default true,
   status varchar(1) not null default 'O',
   flag varchar(45),
@@ -13,8 +16,22 @@ CREATE TABLE IF NOT EXISTS user_roles (
   updated_by bigint default 0,
   deleted_at timestamptz,
   deleted_by bigint default 0,
+  CONSTRAINT user_roles_valid_window CHECK (valid_until IS NULL OR valid_from IS NULL OR valid_until > valid_from),
   CONSTRAINT unique_user_role UNIQUE (user_id, role_id, deleted_at)
 );
 
-CREATE UNIQUE INDEX unique_user_role_active ON user_roles(user_id, role_id)
-WHERE deleted_at IS NULL;
\ No newline at end of file
+CREATE UNIQUE INDEX IF NOT EXISTS unique_user_role_active ON user_roles(user_id, role_id)
+WHERE deleted_at IS NULL;
+
+-- Upgrade existing installations
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS valid_from timestamptz;
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS valid_until timestamptz;
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS expired_at timestamptz;
+
+DO $$
+BEGIN
+  ALTER TABLE user_roles ADD CONSTRAINT user_roles_valid_window CHECK (valid_until IS NULL OR valid_from IS NULL OR valid_until > valid_from);
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS user_roles_valid_until ON user_roles(valid_until) WHERE valid_until IS NOT NULL AND expired_at IS NULL;
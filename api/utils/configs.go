@@ -2,6 +2,7 @@ package utils
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"os"
 	"time"
@@ -15,18 +16,26 @@ import (
 
 type (
 	Config struct {
-		Server   ServerConfig   `yaml:"server"`
-		Database DatabaseConfig `yaml:"database"`
-		Frontend FrontendConfig `yaml:"frontend"`
-		Env      string         `yaml:"env"`
-		SMTP     SMTPConfig     `yaml:"smtp"`
-		Redis    RedisConfig    `yaml:"redis"`
+		Server   ServerConfig          `yaml:"server"`
+		Database DatabaseConfig        `yaml:"database"`
+		Frontend FrontendConfig        `yaml:"frontend"`
+		Env      string                `yaml:"env"`
+		SMTP     SMTPConfig            `yaml:"smtp"`
+		OIDC     map[string]OIDCConfig `yaml:"oidc"`
+		Security SecurityConfig        `yaml:"security"`
+		Storage  StorageConfig         `yaml:"storage"`
+		Redis    RedisConfig           `yaml:"redis"`
 	}
 
 	ServerConfig struct {
-		Host     string `yaml:"host"`
-		Endpoint string `yaml:"endpoint"`
-		JwtKey   string `yaml:"jwt_key"`
+		Host            string        `yaml:"host"`
+		Endpoint        string        `yaml:"endpoint"`
+		JwtKey          string        `yaml:"jwt_key"`
+		JwtAlgorithm    string        `yaml:"jwt_algorithm"`
+		JwtKeyRotation  time.Duration `yaml:"jwt_key_rotation"`
+		BaseURL         string        `yaml:"base_url"`
+		AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+		RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
 	}
 
 	DatabaseConfig struct {
@@ -39,10 +48,47 @@ type (
 	}
 
 	SMTPConfig struct {
-		Host string `yaml:"host"`
-		User string `yaml:"user"`
-		Pass string `yaml:"pass"`
-		Port int    `yaml:"port"`
+		Host   string `yaml:"host"`
+		User   string `yaml:"user"`
+		Pass   string `yaml:"pass"`
+		Port   int    `yaml:"port"`
+		From   string `yaml:"from"`
+		Driver string `yaml:"driver"`
+		Dir    string `yaml:"dir"`
+	}
+
+	SecurityConfig struct {
+		MaxFailedLogins    int           `yaml:"max_failed_logins"`
+		LockoutDuration    time.Duration `yaml:"lockout_duration"`
+		IPMaxFailedLogins  int           `yaml:"ip_max_failed_logins"`
+		FailedLoginWindow  time.Duration `yaml:"failed_login_window"`
+		PermissionCacheTTL time.Duration `yaml:"permission_cache_ttl"`
+		ImpersonationTTL   time.Duration `yaml:"impersonation_ttl"`
+		TOTPEncryptionKey  string        `yaml:"totp_encryption_key"`
+		KeyEncryptionKey   string        `yaml:"key_encryption_key"`
+	}
+
+	StorageConfig struct {
+		Driver        string `yaml:"driver"`
+		Dir           string `yaml:"dir"`
+		BaseURL       string `yaml:"base_url"`
+		Endpoint      string `yaml:"endpoint"`
+		Region        string `yaml:"region"`
+		Bucket        string `yaml:"bucket"`
+		AccessKey     string `yaml:"access_key"`
+		SecretKey     string `yaml:"secret_key"`
+		UseSSL        bool   `yaml:"use_ssl"`
+		MaxUploadSize int64  `yaml:"max_upload_size"`
+		MaxPixels     int64  `yaml:"max_pixels"`
+	}
+
+	OIDCConfig struct {
+		Issuer       string   `yaml:"issuer"`
+		ClientID     string   `yaml:"client_id"`
+		ClientSecret string   `yaml:"client_secret"`
+		RedirectURL  string   `yaml:"redirect_url"`
+		Scopes       []string `yaml:"scopes"`
+		AllowSignup  bool     `yaml:"allow_signup"`
 	}
 
 	RedisConfig struct {
@@ -77,6 +123,52 @@ func InitConfig() Config {
 	return cfg
 }
 
+func (c Config) Validate() error {
+	if c.Database.DSN == "" {
+		return errors.New("database.dsn is required")
+	}
+
+	if c.Security.TOTPEncryptionKey == "" {
+		return errors.New("security.totp_encryption_key is required")
+	}
+
+	if JwtAlgorithm() != "HS256" && c.Security.KeyEncryptionKey == "" {
+		return errors.New("security.key_encryption_key is required for RS256 and EdDSA signing")
+	}
+
+	return nil
+}
+
+func Security() SecurityConfig {
+	sec := cfg.Security
+
+	if sec.MaxFailedLogins <= 0 {
+		sec.MaxFailedLogins = 5
+	}
+
+	if sec.LockoutDuration <= 0 {
+		sec.LockoutDuration = 15 * time.Minute
+	}
+
+	if sec.IPMaxFailedLogins <= 0 {
+		sec.IPMaxFailedLogins = 20
+	}
+
+	if sec.FailedLoginWindow <= 0 {
+		sec.FailedLoginWindow = 15 * time.Minute
+	}
+
+	if sec.PermissionCacheTTL <= 0 {
+		sec.PermissionCacheTTL = 5 * time.Minute
+	}
+
+	if sec.ImpersonationTTL <= 0 {
+		sec.ImpersonationTTL = 15 * time.Minute
+	}
+
+	return sec
+}
+
 func InitDB() *bun.DB {
 	if db != nil {
 		return db
@@ -84,7 +176,12 @@ func InitDB() *bun.DB {
 
 	dbConf := cfg.Database
 
-	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dbConf.DSN)))
+	var opts []pgdriver.Option
+	if dbConf.DSN != "" {
+		opts = append(opts, pgdriver.WithDSN(dbConf.DSN))
+	}
+
+	sqldb := sql.OpenDB(pgdriver.NewConnector(opts...))
 	sqldb.SetMaxOpenConns(25)
 	sqldb.SetMaxIdleConns(5)
 	sqldb.SetConnMaxLifetime(1 * time.Hour)
@@ -92,12 +189,6 @@ func InitDB() *bun.DB {
 
 	db = bun.NewDB(sqldb, pgdialect.New())
 
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
-	}
-
-	log.Println("✅ Database connection established successfully.")
-
 	if dbConf.BunDebug {
 		log.Println("⚙️  Bun debug query hook is enabled.")
 	} else {
@@ -111,3 +202,11 @@ func InitDB() *bun.DB {
 
 	return db
 }
+
+func PingDB() {
+	if err := InitDB().Ping(); err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	log.Println("✅ Database connection established successfully.")
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type (
+	ProcessedImage struct {
+		ContentType string
+		Extension   string
+		Width       int
+		Height      int
+		Thumbnail   []byte
+		WebP        []byte
+	}
+)
+
+const (
+	thumbnailSize = 320
+	webpMaxSize   = 2048
+)
+
+var (
+	ErrUnsupportedMedia = errors.New("unsupported media type, upload a JPEG, PNG, GIF or WebP image")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+func SniffImage(data []byte) (contentType, extension string, err error) {
+	contentType = http.DetectContentType(data)
+
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return contentType, "", ErrUnsupportedMedia
+	}
+
+	return contentType, extension, nil
+}
+
+func ProcessImage(data []byte) (res ProcessedImage, err error) {
+	if res.ContentType, res.Extension, err = SniffImage(data); err != nil {
+		return res, err
+	}
+
+	conf, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return res, ErrUnsupportedMedia
+	}
+
+	if conf.Width <= 0 || conf.Height <= 0 || int64(conf.Width)*int64(conf.Height) > MaxImagePixels() {
+		return res, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return res, ErrUnsupportedMedia
+	}
+
+	res.Width, res.Height = src.Bounds().Dx(), src.Bounds().Dy()
+
+	var thumb bytes.Buffer
+	if err = jpeg.Encode(&thumb, flattenImage(fitImage(src, thumbnailSize)), &jpeg.Options{Quality: 80}); err != nil {
+		return res, err
+	}
+
+	var webp bytes.Buffer
+	if err = nativewebp.Encode(&webp, fitImage(src, webpMaxSize), nil); err != nil {
+		return res, err
+	}
+
+	res.Thumbnail, res.WebP = thumb.Bytes(), webp.Bytes()
+	return res, nil
+}
+
+func fitImage(src image.Image, size int) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	scale := float64(size) / float64(max(w, h))
+	dst := image.NewRGBA(image.Rect(0, 0, max(1, int(float64(w)*scale)), max(1, int(float64(h)*scale))))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	return dst
+}
+
+func flattenImage(src image.Image) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Over)
+
+	return dst
+}
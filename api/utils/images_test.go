@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 200})
+		}
+	}
+
+	return img
+}
+
+func encodeImage(t *testing.T, format string, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var err error
+
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+
+	if err != nil {
+		t.Fatalf("encode %s: %v", format, err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestSniffImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantType  string
+		wantExt   string
+		wantError error
+	}{
+		{"png", encodeImage(t, "png", testImage(4, 4)), "image/png", "png", nil},
+		{"jpeg", encodeImage(t, "jpeg", testImage(4, 4)), "image/jpeg", "jpg", nil},
+		{"gif", encodeImage(t, "gif", testImage(4, 4)), "image/gif", "gif", nil},
+		{"text", []byte("not an image"), "text/plain; charset=utf-8", "", ErrUnsupportedMedia},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "text/xml; charset=utf-8", "", ErrUnsupportedMedia},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, ext, err := SniffImage(tt.data)
+
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("SniffImage() error = %v, want %v", err, tt.wantError)
+			}
+
+			if contentType != tt.wantType || ext != tt.wantExt {
+				t.Errorf("SniffImage() = %q, %q, want %q, %q", contentType, ext, tt.wantType, tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestProcessImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantW     int
+		wantH     int
+		thumbW    int
+		thumbH    int
+		wantError error
+	}{
+		{"small png", encodeImage(t, "png", testImage(40, 20)), 40, 20, 40, 20, nil},
+		{"wide jpeg", encodeImage(t, "jpeg", testImage(640, 320)), 640, 320, 320, 160, nil},
+		{"tall gif", encodeImage(t, "gif", testImage(100, 800)), 100, 800, 40, 320, nil},
+		{"unsupported", []byte("not an image"), 0, 0, 0, 0, ErrUnsupportedMedia},
+		{"truncated png", encodeImage(t, "png", testImage(40, 20))[:40], 0, 0, 0, 0, ErrUnsupportedMedia},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ProcessImage(tt.data)
+
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ProcessImage() error = %v, want %v", err, tt.wantError)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if res.Width != tt.wantW || res.Height != tt.wantH {
+				t.Errorf("dimensions = %dx%d, want %dx%d", res.Width, res.Height, tt.wantW, tt.wantH)
+			}
+
+			thumb, err := jpeg.DecodeConfig(bytes.NewReader(res.Thumbnail))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+
+			if thumb.Width != tt.thumbW || thumb.Height != tt.thumbH {
+				t.Errorf("thumbnail = %dx%d, want %dx%d", thumb.Width, thumb.Height, tt.thumbW, tt.thumbH)
+			}
+
+			conf, err := webp.DecodeConfig(bytes.NewReader(res.WebP))
+			if err != nil {
+				t.Fatalf("webp rendition is not a WebP: %v", err)
+			}
+
+			if conf.Width != tt.wantW || conf.Height != tt.wantH {
+				t.Errorf("webp = %dx%d, want %dx%d", conf.Width, conf.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestProcessImagePixelLimit(t *testing.T) {
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+
+	data := encodeImage(t, "png", testImage(100, 100))
+
+	cfg.Storage.MaxPixels = 10_000
+	if _, err := ProcessImage(data); err != nil {
+		t.Fatalf("ProcessImage() at the limit error = %v", err)
+	}
+
+	cfg.Storage.MaxPixels = 9_999
+	if _, err := ProcessImage(data); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("ProcessImage() over the limit error = %v, want ErrImageTooLarge", err)
+	}
+}
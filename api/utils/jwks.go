@@ -0,0 +1,439 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/uptrace/bun"
+)
+
+type (
+	SigningKey struct {
+		bun.BaseModel `bun:"table:jwt_keys,alias:jk"`
+
+		ID         int64     `bun:"id,pk,autoincrement" json:"id"`
+		Kid        string    `bun:"kid" json:"kid"`
+		Algorithm  string    `bun:"algorithm" json:"algorithm"`
+		PrivateKey string    `bun:"private_key" json:"-"`
+		PublicKey  string    `bun:"public_key" json:"public_key"`
+		CreatedAt  time.Time `bun:"created_at,notnull,default:current_timestamp" json:"created_at"`
+		RetiredAt  time.Time `bun:"retired_at,nullzero,default:null" json:"retired_at,omitzero"`
+		ExpiresAt  time.Time `bun:"expires_at,nullzero,default:null" json:"expires_at,omitzero"`
+	}
+
+	keyring struct {
+		mu       sync.RWMutex
+		current  *loadedKey
+		keys     map[string]*loadedKey
+		loadedAt time.Time
+	}
+
+	loadedKey struct {
+		kid     string
+		method  jwt.SigningMethod
+		private any
+		public  any
+	}
+)
+
+var keys = &keyring{keys: map[string]*loadedKey{}}
+
+func JwtAlgorithm() string {
+	switch cfg.Server.JwtAlgorithm {
+	case "RS256", "EdDSA":
+		return cfg.Server.JwtAlgorithm
+	default:
+		return "HS256"
+	}
+}
+
+func signToken(claims jwt.Claims) (string, error) {
+	if JwtAlgorithm() == "HS256" {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Server.JwtKey))
+	}
+
+	k, err := keys.signing()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+
+	return token.SignedString(k.private)
+}
+
+func parseToken(token string, claims jwt.Claims) (*jwt.Token, error) {
+	alg := JwtAlgorithm()
+
+	keyFunc := func(t *jwt.Token) (any, error) {
+		if alg == "HS256" {
+			return []byte(cfg.Server.JwtKey), nil
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		k, err := keys.verification(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.method.Alg() != t.Method.Alg() {
+			return nil, errors.New("signing method does not match key")
+		}
+
+		return k.public, nil
+	}
+
+	methods := []string{alg}
+	if alg != "HS256" {
+		methods = []string{"RS256", "EdDSA"}
+	}
+
+	return jwt.ParseWithClaims(token, claims, keyFunc, jwt.WithValidMethods(methods))
+}
+
+func JWKS(ctx context.Context) (map[string]any, error) {
+	set := []map[string]string{}
+
+	if JwtAlgorithm() == "HS256" {
+		return map[string]any{"keys": set}, nil
+	}
+
+	var items []SigningKey
+	err := db.NewSelect().Model(&items).
+		WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			return sq.Where("expires_at IS NULL").WhereOr("expires_at > NOW()")
+		}).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		k, err := item.load(false)
+		if err != nil {
+			log.Printf("Error loading signing key %s: %s", item.Kid, err)
+			continue
+		}
+
+		set = append(set, k.jwk())
+	}
+
+	return map[string]any{"keys": set}, nil
+}
+
+func GenerateSigningKey(ctx context.Context, alg string) (item SigningKey, err error) {
+	item, _, err = replaceSigningKey(ctx, alg, 0)
+	return item, err
+}
+
+func RotateSigningKeys(ctx context.Context, alg string, maxAge time.Duration) (rotated bool, err error) {
+	if _, rotated, err = replaceSigningKey(ctx, alg, maxAge); err != nil {
+		return false, err
+	}
+
+	return rotated, PurgeSigningKeys(ctx)
+}
+
+func replaceSigningKey(ctx context.Context, alg string, maxAge time.Duration) (item SigningKey, rotated bool, err error) {
+	err = db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('jwt_keys'))"); err != nil {
+			return err
+		}
+
+		if maxAge > 0 {
+			var current SigningKey
+			err := tx.NewSelect().Model(&current).
+				Where("retired_at IS NULL").
+				Order("created_at DESC").
+				Limit(1).
+				Scan(ctx)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			if err == nil && !current.due(alg, maxAge, time.Now()) {
+				return nil
+			}
+		}
+
+		if item, err = newSigningKey(alg); err != nil {
+			return err
+		}
+
+		_, err := tx.NewUpdate().Model((*SigningKey)(nil)).
+			Set("retired_at = NOW()").
+			Set("expires_at = ?", time.Now().Add(maxTokenLifetime())).
+			Where("retired_at IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.NewInsert().Model(&item).Exec(ctx); err != nil {
+			return err
+		}
+
+		rotated = true
+		return nil
+	})
+
+	if rotated {
+		keys.invalidate()
+	}
+
+	return item, rotated, err
+}
+
+func PurgeSigningKeys(ctx context.Context) error {
+	_, err := db.NewDelete().Model((*SigningKey)(nil)).
+		Where("expires_at IS NOT NULL").
+		Where("expires_at < NOW()").
+		Exec(ctx)
+
+	return err
+}
+
+func StartKeyRotation() {
+	if JwtAlgorithm() == "HS256" || cfg.Server.JwtKeyRotation <= 0 {
+		return
+	}
+
+	rotate := func() {
+		rotated, err := RotateSigningKeys(context.Background(), JwtAlgorithm(), cfg.Server.JwtKeyRotation)
+		if err != nil {
+			log.Printf("Error rotating signing keys: %s", err)
+			return
+		}
+
+		if rotated {
+			log.Println("🔑 Signing key rotated.")
+		}
+	}
+
+	go func() {
+		rotate()
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			rotate()
+		}
+	}()
+}
+
+func maxTokenLifetime() time.Duration {
+	return max(RefreshTokenTTL(), 24*time.Hour) + time.Hour
+}
+
+func newSigningKey(alg string) (item SigningKey, err error) {
+	var private, public any
+
+	switch alg {
+	case "RS256":
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return item, err
+		}
+		private, public = k, &k.PublicKey
+	case "EdDSA":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return item, err
+		}
+		private, public = priv, pub
+	default:
+		return item, fmt.Errorf("unsupported signing algorithm %q, must be RS256 or EdDSA", alg)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return item, err
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return item, err
+	}
+
+	encrypted, err := sealSecret(cfg.Security.KeyEncryptionKey, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})))
+	if err != nil {
+		return item, err
+	}
+
+	kid, err := RandomToken(8)
+	if err != nil {
+		return item, err
+	}
+
+	item = SigningKey{
+		Kid:        kid,
+		Algorithm:  alg,
+		PrivateKey: encrypted,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+	}
+
+	return item, nil
+}
+
+func (k SigningKey) due(alg string, maxAge time.Duration, now time.Time) bool {
+	return k.Algorithm != alg || now.Sub(k.CreatedAt) >= maxAge
+}
+
+func (k SigningKey) load(withPrivate bool) (*loadedKey, error) {
+	block, _ := pem.Decode([]byte(k.PublicKey))
+	if block == nil {
+		return nil, errors.New("invalid public key pem")
+	}
+
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	lk := &loadedKey{kid: k.Kid, public: public, method: jwt.GetSigningMethod(k.Algorithm)}
+	if lk.method == nil {
+		return nil, fmt.Errorf("unknown signing algorithm %q", k.Algorithm)
+	}
+
+	if !withPrivate {
+		return lk, nil
+	}
+
+	decrypted, err := openSecret(cfg.Security.KeyEncryptionKey, k.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ = pem.Decode([]byte(decrypted))
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+
+	if lk.private, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		return nil, err
+	}
+
+	return lk, nil
+}
+
+func (k *loadedKey) jwk() map[string]string {
+	res := map[string]string{"kid": k.kid, "use": "sig", "alg": k.method.Alg()}
+
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		res["kty"] = "RSA"
+		res["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		res["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		res["kty"] = "OKP"
+		res["crv"] = "Ed25519"
+		res["x"] = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return res
+}
+
+func (r *keyring) signing() (*loadedKey, error) {
+	if err := r.refresh(false); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.current == nil {
+		return nil, errors.New("no active signing key, run `go run . keys generate`")
+	}
+
+	return r.current, nil
+}
+
+func (r *keyring) verification(kid string) (*loadedKey, error) {
+	if err := r.refresh(false); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	k, ok := r.keys[kid]
+	r.mu.RUnlock()
+
+	if ok {
+		return k, nil
+	}
+
+	if err := r.refresh(true); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if k, ok := r.keys[kid]; ok {
+		return k, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (r *keyring) invalidate() {
+	r.mu.Lock()
+	r.loadedAt = time.Time{}
+	r.mu.Unlock()
+}
+
+func (r *keyring) refresh(force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !force && time.Since(r.loadedAt) < time.Minute {
+		return nil
+	}
+
+	if force && time.Since(r.loadedAt) < 5*time.Second {
+		return nil
+	}
+
+	var items []SigningKey
+	err := db.NewSelect().Model(&items).
+		WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			return sq.Where("expires_at IS NULL").WhereOr("expires_at > NOW()")
+		}).
+		Order("created_at ASC").
+		Scan(context.Background())
+	if err != nil {
+		return err
+	}
+
+	r.current, r.keys = nil, map[string]*loadedKey{}
+	for _, item := range items {
+		lk, err := item.load(item.RetiredAt.IsZero())
+		if err != nil {
+			log.Printf("Error loading signing key %s: %s", item.Kid, err)
+			continue
+		}
+
+		r.keys[item.Kid] = lk
+		if item.RetiredAt.IsZero() {
+			r.current = lk
+		}
+	}
+
+	r.loadedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func useSigningKeys(t *testing.T, alg string, items ...SigningKey) {
+	t.Helper()
+
+	previous, previousKeys := cfg, keys
+	t.Cleanup(func() { cfg, keys = previous, previousKeys })
+
+	cfg.Server.JwtAlgorithm = alg
+	keys = &keyring{keys: map[string]*loadedKey{}, loadedAt: time.Now()}
+
+	for _, item := range items {
+		lk, err := item.load(item.RetiredAt.IsZero())
+		if err != nil {
+			t.Fatalf("load() error = %v", err)
+		}
+
+		keys.keys[item.Kid] = lk
+		if item.RetiredAt.IsZero() {
+			keys.current = lk
+		}
+	}
+}
+
+func testSigningKey(t *testing.T, alg string) SigningKey {
+	t.Helper()
+
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+
+	cfg.Security.KeyEncryptionKey = "signing-key"
+
+	item, err := newSigningKey(alg)
+	if err != nil {
+		t.Fatalf("newSigningKey(%s) error = %v", alg, err)
+	}
+
+	return item
+}
+
+func TestSigningKeyRoundTrip(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			item := testSigningKey(t, alg)
+			useSigningKeys(t, alg, item)
+
+			raw, err := signToken(jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))})
+			if err != nil {
+				t.Fatalf("signToken() error = %v", err)
+			}
+
+			var claims jwt.RegisteredClaims
+			token, err := parseToken(raw, &claims)
+			if err != nil {
+				t.Fatalf("parseToken() error = %v", err)
+			}
+
+			if token.Header["kid"] != item.Kid || token.Method.Alg() != alg || claims.Subject != "user-1" {
+				t.Errorf("parseToken() = kid %v, alg %s, sub %s", token.Header["kid"], token.Method.Alg(), claims.Subject)
+			}
+		})
+	}
+}
+
+func TestSigningKeyEncryption(t *testing.T) {
+	item := testSigningKey(t, "EdDSA")
+
+	if strings.Contains(item.PrivateKey, "PRIVATE KEY") {
+		t.Fatal("private key is stored in clear text")
+	}
+
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+
+	cfg.Security.KeyEncryptionKey = "other-key"
+	if _, err := item.load(true); err == nil {
+		t.Error("load() with the wrong key encryption key should fail")
+	}
+
+	cfg.Security.TOTPEncryptionKey = "signing-key"
+	cfg.Security.KeyEncryptionKey = ""
+	if _, err := item.load(true); err == nil {
+		t.Error("load() should not fall back to the totp encryption key")
+	}
+
+	if _, err := item.load(false); err != nil {
+		t.Errorf("load() of the public key error = %v", err)
+	}
+}
+
+func TestSigningKeyJWK(t *testing.T) {
+	tests := []struct {
+		alg    string
+		kty    string
+		fields []string
+	}{
+		{"RS256", "RSA", []string{"n", "e"}},
+		{"EdDSA", "OKP", []string{"crv", "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			item := testSigningKey(t, tt.alg)
+
+			lk, err := item.load(false)
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+
+			jwk := lk.jwk()
+			if jwk["kid"] != item.Kid || jwk["alg"] != tt.alg || jwk["kty"] != tt.kty || jwk["use"] != "sig" {
+				t.Errorf("jwk() = %v", jwk)
+			}
+
+			for _, f := range tt.fields {
+				if jwk[f] == "" {
+					t.Errorf("jwk() is missing %q: %v", f, jwk)
+				}
+			}
+
+			switch pub := lk.public.(type) {
+			case *rsa.PublicKey:
+				if jwk["n"] != base64.RawURLEncoding.EncodeToString(pub.N.Bytes()) {
+					t.Errorf("jwk() modulus does not match the public key")
+				}
+			case ed25519.PublicKey:
+				if jwk["x"] != base64.RawURLEncoding.EncodeToString(pub) {
+					t.Errorf("jwk() x does not match the public key")
+				}
+			}
+		})
+	}
+}
+
+func TestJWKSWithHMAC(t *testing.T) {
+	useSigningKeys(t, "HS256")
+
+	set, err := JWKS(context.Background())
+	if err != nil {
+		t.Fatalf("JWKS() error = %v", err)
+	}
+
+	if got := set["keys"].([]map[string]string); len(got) != 0 {
+		t.Errorf("JWKS() = %v, want no keys for HS256", got)
+	}
+}
+
+func TestSigningKeyLookup(t *testing.T) {
+	retired := testSigningKey(t, "RS256")
+	retired.RetiredAt = time.Now()
+	current := testSigningKey(t, "EdDSA")
+
+	useSigningKeys(t, "EdDSA", retired, current)
+
+	if k, err := keys.signing(); err != nil || k.kid != current.Kid {
+		t.Fatalf("signing() = %v, %v, want %s", k, err, current.Kid)
+	}
+
+	for _, kid := range []string{retired.Kid, current.Kid} {
+		if _, err := keys.verification(kid); err != nil {
+			t.Errorf("verification(%s) error = %v", kid, err)
+		}
+	}
+
+	if _, err := keys.verification("unknown"); err == nil {
+		t.Error("verification() of an unknown kid should fail")
+	}
+
+	if keys.keys[retired.Kid].private != nil {
+		t.Error("retired keys should only be loaded for verification")
+	}
+}
+
+func TestSigningKeyDue(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		key  SigningKey
+		alg  string
+		want bool
+	}{
+		{"fresh", SigningKey{Algorithm: "RS256", CreatedAt: now.Add(-time.Hour)}, "RS256", false},
+		{"expired", SigningKey{Algorithm: "RS256", CreatedAt: now.Add(-31 * 24 * time.Hour)}, "RS256", true},
+		{"at max age", SigningKey{Algorithm: "RS256", CreatedAt: now.Add(-30 * 24 * time.Hour)}, "RS256", true},
+		{"algorithm changed", SigningKey{Algorithm: "RS256", CreatedAt: now}, "EdDSA", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.due(tt.alg, 30*24*time.Hour, now); got != tt.want {
+				t.Errorf("due() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxTokenLifetime(t *testing.T) {
+	if got := maxTokenLifetime(); got <= RefreshTokenTTL() || got <= 24*time.Hour {
+		t.Errorf("maxTokenLifetime() = %s, retired keys must outlive refresh tokens (%s)", got, RefreshTokenTTL())
+	}
+}
@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,97 +15,175 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	AccessToken      = "access"
+	RefreshToken     = "refresh"
+	VerifyEmailToken = "verify_email"
+
+	TwoFactorChallengeToken = "2fa_challenge"
+	TwoFactorEnrollToken    = "2fa_enroll"
+)
+
 type (
 	JwtClaim struct {
-		Username string `json:"username"`
-		UUID     string `json:"uuid"`
+		Username     string `json:"username"`
+		UUID         string `json:"uuid"`
+		SessionID    string `json:"sid,omitempty"`
+		Impersonator string `json:"imp,omitempty"`
+		Type         string `json:"typ"`
 		jwt.RegisteredClaims
 	}
 
 	Tokens struct {
-		Username     string           `json:"username"`
-		UUID         string           `json:"uuid"`
-		AccessToken  string           `json:"access_token"`
-		RefreshToken string           `json:"refresh_token"`
-		ExpiresAt    *jwt.NumericDate `json:"expires_at"`
+		Username         string           `json:"username"`
+		UUID             string           `json:"uuid"`
+		SessionID        string           `json:"session_id"`
+		Impersonator     string           `json:"impersonator,omitempty"`
+		AccessToken      string           `json:"access_token"`
+		RefreshToken     string           `json:"refresh_token"`
+		ExpiresAt        *jwt.NumericDate `json:"expires_at"`
+		RefreshExpiresAt *jwt.NumericDate `json:"refresh_expires_at"`
 	}
 )
 
-func GenerateJWT(uuid, username string) (*Tokens, error) {
-	jwtKey := []byte(cfg.Server.JwtKey)
-
-	generateToken := func(expiration time.Duration, uuid, username string) (string, error) {
-		claims := registerToken(expiration, uuid, username)
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		return token.SignedString(jwtKey)
+func GenerateJWT(uuid, username, sessionID string) (*Tokens, error) {
+	generateToken := func(expiration time.Duration, tokenType string) (string, error) {
+		return signToken(registerToken(expiration, uuid, username, sessionID, tokenType))
 	}
 
-	token, err := generateToken(15*time.Minute, uuid, username)
+	accessTTL, refreshTTL := AccessTokenTTL(), RefreshTokenTTL()
+
+	token, err := generateToken(accessTTL, AccessToken)
 	if err != nil {
 		log.Printf("Error signing access token: %s", err)
 		return nil, err
 	}
 
-	refreshToken, err := generateToken(time.Hour, uuid, username)
+	refreshToken, err := generateToken(refreshTTL, RefreshToken)
 	if err != nil {
 		log.Printf("Error signing refresh token: %s", err)
 		return nil, err
 	}
 
+	now := time.Now()
+	res := &Tokens{
+		UUID:             uuid,
+		Username:         username,
+		SessionID:        sessionID,
+		AccessToken:      token,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        jwt.NewNumericDate(now.Add(accessTTL)),
+		RefreshExpiresAt: jwt.NewNumericDate(now.Add(refreshTTL)),
+	}
+
+	return res, nil
+}
+
+func GenerateImpersonationJWT(uuid, username, sessionID, impersonator string) (*Tokens, error) {
+	ttl := Security().ImpersonationTTL
+
+	claims := registerToken(ttl, uuid, username, sessionID, AccessToken)
+	claims.Impersonator = impersonator
+
+	token, err := signToken(claims)
+	if err != nil {
+		log.Printf("Error signing impersonation token: %s", err)
+		return nil, err
+	}
+
 	res := &Tokens{
 		UUID:         uuid,
 		Username:     username,
+		SessionID:    sessionID,
+		Impersonator: impersonator,
 		AccessToken:  token,
-		RefreshToken: refreshToken,
-		ExpiresAt:    jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+		ExpiresAt:    claims.ExpiresAt,
 	}
 
 	return res, nil
 }
 
-func VerifyJWT(token, uuid string) error {
-	if token == "" || uuid == "" {
-		return errors.New("empty token or uuid")
+func GenerateActionToken(uuid, tokenType string, expiration time.Duration) (string, error) {
+	return signToken(registerToken(expiration, uuid, "", "", tokenType))
+}
+
+func VerifyJWT(token, tokenType string) (*JwtClaim, error) {
+	if token == "" {
+		return nil, errors.New("empty token")
+	}
+
+	claims := &JwtClaim{}
+	t, err := parseToken(token, claims)
+
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, errors.New("token expired")
 	}
 
-	jwtKey := []byte(cfg.Server.JwtKey)
+	if err != nil || !t.Valid || claims.UUID == "" {
+		return nil, errors.New("invalid token or claims")
+	}
 
-	keyFunc := func(token *jwt.Token) (any, error) {
-		return []byte(jwtKey), nil
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("invalid token type, expected %s token", tokenType)
 	}
 
-	t, err := jwt.ParseWithClaims(token, &JwtClaim{}, keyFunc)
-	claims, _ := t.Claims.(*JwtClaim)
+	return claims, nil
+}
 
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-		return errors.New("token expired")
+func AccessTokenTTL() time.Duration {
+	if cfg.Server.AccessTokenTTL > 0 {
+		return cfg.Server.AccessTokenTTL
 	}
 
-	if claims.UUID != uuid {
-		return errors.New("unauthorized - UUID mismatch")
+	return 15 * time.Minute
+}
+
+func RefreshTokenTTL() time.Duration {
+	if cfg.Server.RefreshTokenTTL > 0 {
+		return cfg.Server.RefreshTokenTTL
 	}
 
-	if err != nil {
-		return errors.New("invalid token or claims")
+	return time.Hour
+}
+
+func BaseURL() string {
+	if cfg.Server.BaseURL != "" {
+		return strings.TrimRight(cfg.Server.BaseURL, "/")
 	}
 
-	return nil
+	return "http://" + cfg.Server.Host
 }
 
-func RefreshJWT(uuid, username string) (string, error) {
-	jwtKey := []byte(cfg.Server.JwtKey)
+func FrontendURL() string {
+	if cfg.Frontend.Source != "" {
+		return strings.TrimRight(cfg.Frontend.Source, "/")
+	}
 
-	refreshExpiration := time.Hour
+	return BaseURL()
+}
 
-	claims := registerToken(refreshExpiration, uuid, username)
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-	refreshTokenString, err := refreshToken.SignedString(jwtKey)
-	if err != nil {
-		return "", fmt.Errorf("failed to sign refresh token: %w", err)
+func RandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return refreshTokenString, nil
+	return hex.EncodeToString(b), nil
+}
+
+func NewUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
 }
 
 func HandlePassword(action, password, hashedPassword string) (string, error) {
@@ -126,16 +208,28 @@ func HandlePassword(action, password, hashedPassword string) (string, error) {
 }
 
 func SetCooke(ctx *gin.Context, jwt *Tokens) {
-	ctx.SetCookie("access_token", jwt.AccessToken, 900, "/", "localhost", false, true)
-	ctx.SetCookie("refresh_token", jwt.RefreshToken, 3600, "/", "localhost", false, true)
+	ctx.SetCookie("access_token", jwt.AccessToken, int(AccessTokenTTL().Seconds()), "/", "localhost", false, true)
+	ctx.SetCookie("refresh_token", jwt.RefreshToken, int(RefreshTokenTTL().Seconds()), "/", "localhost", false, true)
 	ctx.SetCookie("uuid", jwt.UUID, 0, "/", "localhost", false, true)
 }
 
-func registerToken(duration time.Duration, uuid, username string) (claims *JwtClaim) {
+func ClearCookie(ctx *gin.Context) {
+	ctx.SetCookie("access_token", "", -1, "/", "localhost", false, true)
+	ctx.SetCookie("refresh_token", "", -1, "/", "localhost", false, true)
+	ctx.SetCookie("uuid", "", -1, "/", "localhost", false, true)
+}
+
+func registerToken(duration time.Duration, uuid, username, sessionID, tokenType string) (claims *JwtClaim) {
+	jti, _ := RandomToken(16)
+
 	claims = &JwtClaim{
-		UUID:     uuid,
-		Username: username,
+		UUID:      uuid,
+		Username:  username,
+		SessionID: sessionID,
+		Type:      tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 		},
 	}
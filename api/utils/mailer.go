@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	Mailer interface {
+		Send(mail Mail) error
+	}
+
+	Mail struct {
+		To      string
+		Subject string
+		Body    string
+	}
+
+	SMTPMailer struct {
+		Config SMTPConfig
+	}
+
+	FileMailer struct {
+		Dir string
+	}
+
+	MemoryMailer struct {
+		mu    sync.Mutex
+		Mails []Mail
+	}
+)
+
+var mailer = NewMailer(cfg.SMTP)
+
+func NewMailer(conf SMTPConfig) Mailer {
+	switch conf.Driver {
+	case "file":
+		return &FileMailer{Dir: conf.Dir}
+	case "memory":
+		return &MemoryMailer{}
+	default:
+		return &SMTPMailer{Config: conf}
+	}
+}
+
+func SetMailer(m Mailer) {
+	mailer = m
+}
+
+func SendMail(to, subject, body string) error {
+	return mailer.Send(Mail{To: to, Subject: subject, Body: body})
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	if m.Config.Host == "" {
+		return fmt.Errorf("smtp host is not configured")
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.Config.Host, m.Config.Port)
+
+	var auth smtp.Auth
+	if m.Config.User != "" {
+		auth = smtp.PlainAuth("", m.Config.User, m.Config.Pass, m.Config.Host)
+	}
+
+	return smtp.SendMail(addr, auth, m.Config.From, []string{mail.To}, mail.message(m.Config.From))
+}
+
+func (m *FileMailer) Send(mail Mail) error {
+	dir := m.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.ReplaceAll(mail.To, "@", "_at_"))
+	return os.WriteFile(filepath.Join(dir, name), mail.message(""), 0o644)
+}
+
+func (m *MemoryMailer) Send(mail Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Mails = append(m.Mails, mail)
+	return nil
+}
+
+func (m *MemoryMailer) Last() (Mail, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.Mails) == 0 {
+		return Mail{}, false
+	}
+
+	return m.Mails[len(m.Mails)-1], true
+}
+
+func (mail Mail) message(from string) []byte {
+	var b strings.Builder
+
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(mail.Body)
+
+	return []byte(b.String())
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{"", "*utils.SMTPMailer"},
+		{"smtp", "*utils.SMTPMailer"},
+		{"file", "*utils.FileMailer"},
+		{"memory", "*utils.MemoryMailer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			m := NewMailer(SMTPConfig{Driver: tt.driver})
+
+			if got := fmt.Sprintf("%T", m); got != tt.want {
+				t.Errorf("NewMailer(%q) = %s, want %s", tt.driver, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	m := &MemoryMailer{}
+
+	if _, ok := m.Last(); ok {
+		t.Fatal("Last() on an empty mailer reported a mail")
+	}
+
+	previous := mailer
+	SetMailer(m)
+	t.Cleanup(func() { SetMailer(previous) })
+
+	mails := []Mail{
+		{To: "first@example.com", Subject: "Welcome", Body: "hello"},
+		{To: "second@example.com", Subject: "Verify your email", Body: "token"},
+	}
+
+	for _, mail := range mails {
+		if err := SendMail(mail.To, mail.Subject, mail.Body); err != nil {
+			t.Fatalf("SendMail(%q) error = %v", mail.To, err)
+		}
+	}
+
+	if len(m.Mails) != len(mails) {
+		t.Fatalf("got %d mails, want %d", len(m.Mails), len(mails))
+	}
+
+	last, ok := m.Last()
+	if !ok || last != mails[len(mails)-1] {
+		t.Errorf("Last() = %+v, %v, want %+v", last, ok, mails[len(mails)-1])
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := &FileMailer{Dir: dir}
+
+	if err := m.Send(Mail{To: "user@example.com", Subject: "Reset your password", Body: "reset-token"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d files, want 1", len(entries))
+	}
+
+	name := entries[0].Name()
+	if !strings.HasSuffix(name, "-user_at_example.com.eml") {
+		t.Errorf("file name = %q, want suffix %q", name, "-user_at_example.com.eml")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	for _, want := range []string{"To: user@example.com\r\n", "Subject: Reset your password\r\n", "\r\n\r\nreset-token"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("message missing %q:\n%s", want, data)
+		}
+	}
+
+	if strings.Contains(string(data), "From:") {
+		t.Errorf("file message should not carry a From header:\n%s", data)
+	}
+}
+
+func TestSMTPMailerRequiresHost(t *testing.T) {
+	m := &SMTPMailer{}
+
+	if err := m.Send(Mail{To: "user@example.com"}); err == nil {
+		t.Error("Send() without a host should fail")
+	}
+}
@@ -0,0 +1,401 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const OIDCStateToken = "oidc_state"
+
+type (
+	OIDCMetadata struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+		JwksURI               string `json:"jwks_uri"`
+	}
+
+	OIDCClaims struct {
+		Email             string   `json:"email"`
+		EmailVerified     flexBool `json:"email_verified"`
+		Name              string   `json:"name"`
+		GivenName         string   `json:"given_name"`
+		FamilyName        string   `json:"family_name"`
+		PreferredUsername string   `json:"preferred_username"`
+		Nonce             string   `json:"nonce"`
+		jwt.RegisteredClaims
+	}
+
+	OIDCStateClaim struct {
+		Provider string `json:"provider"`
+		State    string `json:"state"`
+		Nonce    string `json:"nonce"`
+		Verifier string `json:"verifier"`
+		LinkUser string `json:"link,omitempty"`
+		Type     string `json:"typ"`
+		jwt.RegisteredClaims
+	}
+
+	OIDCClient struct {
+		Name   string
+		Config OIDCConfig
+		HTTP   *http.Client
+
+		mu        sync.Mutex
+		metadata  *OIDCMetadata
+		keys      map[string]any
+		keysFetch time.Time
+	}
+
+	flexBool bool
+)
+
+var (
+	oidcClients   = map[string]*OIDCClient{}
+	oidcClientsMu sync.Mutex
+
+	ErrOIDCProviderNotFound = errors.New("oidc provider is not configured")
+)
+
+func OIDCProvider(name string) (*OIDCClient, error) {
+	oidcClientsMu.Lock()
+	defer oidcClientsMu.Unlock()
+
+	if c, ok := oidcClients[name]; ok {
+		return c, nil
+	}
+
+	conf, ok := cfg.OIDC[name]
+	if !ok || conf.Issuer == "" {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	c := NewOIDCClient(name, conf)
+	oidcClients[name] = c
+
+	return c, nil
+}
+
+func NewOIDCClient(name string, conf OIDCConfig) *OIDCClient {
+	return &OIDCClient{
+		Name:   name,
+		Config: conf,
+		HTTP:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *OIDCClient) Discover(ctx context.Context) (*OIDCMetadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.metadata != nil {
+		return c.metadata, nil
+	}
+
+	var meta OIDCMetadata
+	wellKnown := strings.TrimRight(c.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, wellKnown, &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+
+	if strings.TrimRight(meta.Issuer, "/") != strings.TrimRight(c.Config.Issuer, "/") {
+		return nil, fmt.Errorf("oidc discovery issuer mismatch: %s", meta.Issuer)
+	}
+
+	c.metadata = &meta
+	return c.metadata, nil
+}
+
+func (c *OIDCClient) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := c.Config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", c.Config.ClientID)
+	v.Set("redirect_uri", c.Config.RedirectURL)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return meta.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+func (c *OIDCClient) Exchange(ctx context.Context, code, verifier string) (idToken string, err error) {
+	meta, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.Config.RedirectURL)
+	form.Set("client_id", c.Config.ClientID)
+	form.Set("client_secret", c.Config.ClientSecret)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("oidc token response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("oidc token exchange failed: %s %s", body.Error, body.ErrorDescription)
+	}
+
+	if body.IDToken == "" {
+		return "", errors.New("oidc token response has no id_token")
+	}
+
+	return body.IDToken, nil
+}
+
+func (c *OIDCClient) VerifyIDToken(ctx context.Context, raw, nonce string) (*OIDCClaims, error) {
+	meta, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFunc := func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return c.key(ctx, meta.JwksURI, kid)
+	}
+
+	claims := &OIDCClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(c.Config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: missing subject")
+	}
+
+	return claims, nil
+}
+
+func (c *OIDCClient) key(ctx context.Context, jwksURI, kid string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if k, ok := c.lookupKey(kid); ok {
+		return k, nil
+	}
+
+	if time.Since(c.keysFetch) < 10*time.Second {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks fetch failed: %w", err)
+	}
+
+	c.keys = map[string]any{}
+	c.keysFetch = time.Now()
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		if pub, err := k.publicKey(); err == nil {
+			c.keys[k.Kid] = pub
+		}
+	}
+
+	if k, ok := c.lookupKey(kid); ok {
+		return k, nil
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (c *OIDCClient) lookupKey(kid string) (any, bool) {
+	if kid != "" {
+		k, ok := c.keys[kid]
+		return k, ok
+	}
+
+	if len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+
+	return nil, false
+}
+
+func (c *OIDCClient) getJSON(ctx context.Context, u string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, u)
+	}
+
+	return json.NewDecoder(res.Body).Decode(dest)
+}
+
+func GenerateOIDCState(provider, state, nonce, verifier, linkUser string) (string, error) {
+	claims := &OIDCStateClaim{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		LinkUser: linkUser,
+		Type:     OIDCStateToken,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(10 * time.Minute)),
+		},
+	}
+
+	return signToken(claims)
+}
+
+func VerifyOIDCState(token string) (*OIDCStateClaim, error) {
+	claims := &OIDCStateClaim{}
+	_, err := parseToken(token, claims)
+	if err != nil || claims.Type != OIDCStateToken {
+		return nil, errors.New("invalid or expired oidc state")
+	}
+
+	return claims, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = flexBool(s == "true" || s == "1")
+	return nil
+}
@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testProvider struct {
+	*httptest.Server
+
+	key      *rsa.PrivateKey
+	issuer   string
+	metaIss  string
+	code     string
+	verifier string
+	idToken  string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	p := &testProvider{key: key, code: "auth-code", verifier: "pkce-verifier"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := p.issuer
+		if p.metaIss != "" {
+			issuer = p.metaIss
+		}
+
+		json.NewEncoder(w).Encode(OIDCMetadata{
+			Issuer:                issuer,
+			AuthorizationEndpoint: p.URL + "/authorize",
+			TokenEndpoint:         p.URL + "/token",
+			JwksURI:               p.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		if r.Form.Get("code") != p.code || r.Form.Get("code_verifier") != p.verifier {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "bad code"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken})
+	})
+
+	p.Server = httptest.NewServer(mux)
+	p.issuer = p.URL
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *testProvider) client() *OIDCClient {
+	return NewOIDCClient("test", OIDCConfig{
+		Issuer:       p.issuer,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://app.example.com/callback",
+	})
+}
+
+func (p *testProvider) sign(t *testing.T, kid string, claims OIDCClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	raw, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	return raw
+}
+
+func (p *testProvider) claims() OIDCClaims {
+	return OIDCClaims{
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Nonce:         "nonce",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.issuer,
+			Subject:   "subject-1",
+			Audience:  jwt.ClaimStrings{"client-id"},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestOIDCDiscover(t *testing.T) {
+	p := newTestProvider(t)
+
+	meta, err := p.client().Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if meta.TokenEndpoint != p.URL+"/token" {
+		t.Errorf("TokenEndpoint = %q, want %q", meta.TokenEndpoint, p.URL+"/token")
+	}
+
+	p.metaIss = "https://other.example.com"
+	if _, err := p.client().Discover(context.Background()); err == nil {
+		t.Error("Discover() with a mismatched issuer should fail")
+	}
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	p := newTestProvider(t)
+
+	raw, err := p.client().AuthCodeURL(context.Background(), "state", "nonce", p.verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	challenge := sha256.Sum256([]byte(p.verifier))
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client-id",
+		"redirect_uri":          "https://app.example.com/callback",
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+	}
+
+	if !strings.HasPrefix(raw, p.URL+"/authorize?") {
+		t.Errorf("AuthCodeURL() = %q, want the authorization endpoint", raw)
+	}
+
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	p := newTestProvider(t)
+	p.idToken = "id-token"
+
+	tests := []struct {
+		name     string
+		code     string
+		verifier string
+		wantErr  bool
+	}{
+		{"valid code", p.code, p.verifier, false},
+		{"wrong code", "other", p.verifier, true},
+		{"wrong verifier", p.code, "other", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.client().Exchange(context.Background(), tt.code, tt.verifier)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != p.idToken {
+				t.Errorf("Exchange() = %q, want %q", got, p.idToken)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	p := newTestProvider(t)
+
+	tests := []struct {
+		name    string
+		kid     string
+		nonce   string
+		mutate  func(*OIDCClaims)
+		wantErr bool
+	}{
+		{"valid", "test-key", "nonce", func(*OIDCClaims) {}, false},
+		{"nonce mismatch", "test-key", "other", func(*OIDCClaims) {}, true},
+		{"empty nonce", "test-key", "", func(c *OIDCClaims) { c.Nonce = "" }, true},
+		{"wrong audience", "test-key", "nonce", func(c *OIDCClaims) { c.Audience = jwt.ClaimStrings{"other"} }, true},
+		{"wrong issuer", "test-key", "nonce", func(c *OIDCClaims) { c.Issuer = "https://other.example.com" }, true},
+		{"expired", "test-key", "nonce", func(c *OIDCClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) }, true},
+		{"no expiry", "test-key", "nonce", func(c *OIDCClaims) { c.ExpiresAt = nil }, true},
+		{"missing subject", "test-key", "nonce", func(c *OIDCClaims) { c.Subject = "" }, true},
+		{"unknown key", "other-key", "nonce", func(*OIDCClaims) {}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := p.claims()
+			tt.mutate(&claims)
+
+			got, err := p.client().VerifyIDToken(context.Background(), p.sign(t, tt.kid, claims), tt.nonce)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (got.Subject != "subject-1" || got.Email != "user@example.com" || !bool(got.EmailVerified)) {
+				t.Errorf("VerifyIDToken() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyIDTokenRejectsHMAC(t *testing.T) {
+	p := newTestProvider(t)
+
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, p.claims()).SignedString([]byte("client-secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	if _, err := p.client().VerifyIDToken(context.Background(), raw, "nonce"); err == nil {
+		t.Error("VerifyIDToken() should reject HS256 tokens")
+	}
+}
+
+func TestFlexBool(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{`true`, true},
+		{`"true"`, true},
+		{`1`, true},
+		{`false`, false},
+		{`"false"`, false},
+		{`null`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var b flexBool
+			if err := json.Unmarshal([]byte(tt.input), &b); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if bool(b) != tt.want {
+				t.Errorf("flexBool(%s) = %v, want %v", tt.input, b, tt.want)
+			}
+		})
+	}
+}
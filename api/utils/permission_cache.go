@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type (
+	permissionCache struct {
+		mu         sync.RWMutex
+		entries    map[permissionKey]permissionEntry
+		generation uint64
+		sweptAt    time.Time
+		hits       atomic.Uint64
+		misses     atomic.Uint64
+	}
+
+	permissionKey struct {
+		userID         int64
+		organizationID int64
+	}
+
+	permissionEntry struct {
+		permissions []string
+		expiresAt   time.Time
+	}
+
+	PermissionCacheStats struct {
+		Hits     uint64  `json:"hits"`
+		Misses   uint64  `json:"misses"`
+		HitRatio float64 `json:"hit_ratio"`
+		Entries  int     `json:"entries"`
+	}
+)
+
+var permissions = &permissionCache{entries: map[permissionKey]permissionEntry{}}
+
+func UserPermissions(ctx context.Context, userID int64) ([]string, error) {
+	return permissions.resolve(ctx, permissionKey{userID: userID}, func() *bun.SelectQuery {
+		return userPermissionsQuery(userID)
+	})
+}
+
+func OrganizationPermissions(ctx context.Context, userID, organizationID int64) ([]string, error) {
+	return permissions.resolve(ctx, permissionKey{userID: userID, organizationID: organizationID}, func() *bun.SelectQuery {
+		return db.NewSelect().
+			WithRecursive("RoleTree", roleTree()).
+			TableExpr("organization_members AS om").
+			ColumnExpr("DISTINCT p.name").
+			Join("JOIN organizations o ON o.id = om.organization_id AND "+effective("o")).
+			Join(`JOIN "RoleTree" rt ON rt.role_id = om.role_id`).
+			Join("JOIN role_permissions rp ON rp.role_id = rt.ancestor_id AND "+effective("rp")).
+			Join("JOIN permissions p ON p.id = rp.permission_id AND "+effective("p")).
+			Where("om.user_id = ?", userID).
+			Where("om.organization_id = ?", organizationID).
+			Where("om.accepted_at IS NOT NULL").
+			Where(effective("om"))
+	})
+}
+
+func InvalidatePermissions(userIDs ...int64) {
+	permissions.mu.Lock()
+	defer permissions.mu.Unlock()
+
+	permissions.generation++
+
+	if len(userIDs) == 0 {
+		permissions.entries = map[permissionKey]permissionEntry{}
+		return
+	}
+
+	for key := range permissions.entries {
+		if slices.Contains(userIDs, key.userID) {
+			delete(permissions.entries, key)
+		}
+	}
+}
+
+func PermissionStats() PermissionCacheStats {
+	permissions.mu.RLock()
+	entries := len(permissions.entries)
+	permissions.mu.RUnlock()
+
+	stats := PermissionCacheStats{
+		Hits:    permissions.hits.Load(),
+		Misses:  permissions.misses.Load(),
+		Entries: entries,
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+func (c *permissionCache) resolve(ctx context.Context, key permissionKey, query func() *bun.SelectQuery) ([]string, error) {
+	if perms, ok := c.get(key); ok {
+		c.hits.Add(1)
+		return perms, nil
+	}
+
+	c.misses.Add(1)
+
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+
+	perms := []string{}
+	if err := query().Scan(ctx, &perms); err != nil {
+		return nil, err
+	}
+
+	c.set(key, perms, generation)
+	return perms, nil
+}
+
+func (c *permissionCache) get(key permissionKey) ([]string, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(e.expiresAt) {
+		c.mu.Lock()
+		if e, ok := c.entries[key]; ok && time.Now().After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+
+		return nil, false
+	}
+
+	return e.permissions, true
+}
+
+func (c *permissionCache) set(key permissionKey, perms []string, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	now, ttl := time.Now(), Security().PermissionCacheTTL
+	c.entries[key] = permissionEntry{permissions: perms, expiresAt: now.Add(ttl)}
+
+	if now.Sub(c.sweptAt) > ttl {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+
+		c.sweptAt = now
+	}
+}
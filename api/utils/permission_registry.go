@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
+type (
+	RegisteredPermission struct {
+		Name   string   `json:"name"`
+		Routes []string `json:"routes"`
+	}
+
+	permissionRegistry struct {
+		mu      sync.Mutex
+		entries map[string]*RegisteredPermission
+		pending []string
+	}
+)
+
+var registry = &permissionRegistry{entries: map[string]*RegisteredPermission{}}
+
+func RegisterPermission(names ...string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, name := range names {
+		if _, ok := registry.entries[name]; !ok {
+			registry.entries[name] = &RegisteredPermission{Name: name, Routes: []string{}}
+		}
+	}
+
+	registry.pending = append(registry.pending, names...)
+}
+
+func BindPermissionRoute(method, path string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	route := method + " " + path
+	for _, name := range registry.pending {
+		if e := registry.entries[name]; !slices.Contains(e.Routes, route) {
+			e.Routes = append(e.Routes, route)
+		}
+	}
+
+	registry.pending = nil
+}
+
+func PermissionCatalog() []RegisteredPermission {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	items := make([]RegisteredPermission, 0, len(registry.entries))
+	for _, e := range registry.entries {
+		items = append(items, RegisteredPermission{Name: e.Name, Routes: slices.Clone(e.Routes)})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
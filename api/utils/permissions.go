@@ -1,41 +1,271 @@
 package utils
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/uptrace/bun"
 )
 
+const PermissionWildcard = "*"
+
+type (
+	PermissionSource struct {
+		Permission string           `bun:"permission" json:"permission"`
+		Via        string           `bun:"via" json:"via"`
+		Chain      []PermissionLink `bun:"chain,type:jsonb" json:"chain"`
+		Effective  bool             `bun:"effective" json:"effective"`
+		Ignored    string           `bun:"-" json:"ignored_reason,omitempty"`
+	}
+
+	PermissionLink struct {
+		Type       string     `json:"type"`
+		Name       string     `json:"name,omitempty"`
+		UUID       string     `json:"uuid"`
+		ValidFrom  *time.Time `json:"valid_from,omitempty"`
+		ValidUntil *time.Time `json:"valid_until,omitempty"`
+		State      string     `json:"state"`
+	}
+)
+
+var impliedActions = map[string][]string{
+	"manage": {"create", "edit", "read", "delete", "update_status"},
+	"edit":   {"read"},
+}
+
 func GetPermissions(fn func(*bun.SelectQuery) *bun.SelectQuery, ctx *gin.Context, dest ...any) error {
+	var userID int64
+
 	q := db.NewSelect().
-		With("UserPermissions", userPermissionsUnion()).
 		TableExpr("users AS u").
-		Column("u.*").
-		ColumnExpr("JSON_ARRAYAGG(p.name) AS permissions").
-		Join(`LEFT JOIN "UserPermissions" up ON up.user_id = u.id`).
-		Join("LEFT JOIN permissions p ON p.id = up.permission_id").
+		Column("u.id").
 		WhereGroup("AND", func(sq *bun.SelectQuery) *bun.SelectQuery {
 			return sq.Where("u.status = 'O'").
 				Where("u.deleted_at IS NULL")
 		}).
-		Group("u.id")
+		Limit(1)
 
 	if fn != nil {
 		q = fn(q)
 	}
 
-	return q.Scan(ctx, &dest)
+	if err := q.Scan(ctx, &userID); err != nil {
+		return err
+	}
+
+	perms, err := UserPermissions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(perms)
+	if err != nil {
+		return err
+	}
+
+	return db.NewSelect().
+		TableExpr("users AS u").
+		Column("u.*").
+		ColumnExpr("?::jsonb AS permissions", string(raw)).
+		Where("u.id = ?", userID).
+		Scan(ctx, dest...)
+}
+
+func HasPermission(granted []string, required ...string) bool {
+	for _, r := range required {
+		for _, g := range granted {
+			if MatchPermission(g, r) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func MatchPermission(granted, required string) bool {
+	if granted == PermissionWildcard || granted == required {
+		return true
+	}
+
+	gModule, gAction, ok := strings.Cut(granted, ":")
+	if !ok {
+		return false
+	}
+
+	rModule, rAction, ok := strings.Cut(required, ":")
+	if !ok {
+		return false
+	}
+
+	if gModule != PermissionWildcard && gModule != rModule {
+		return false
+	}
+
+	return gAction == PermissionWildcard || impliesAction(gAction, rAction, nil)
+}
+
+func impliesAction(granted, required string, seen []string) bool {
+	if granted == required {
+		return true
+	}
+
+	if slices.Contains(seen, granted) {
+		return false
+	}
+
+	for _, a := range impliedActions[granted] {
+		if impliesAction(a, required, append(seen, granted)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ExplainPermissions(ctx context.Context, userID int64) (items []PermissionSource, err error) {
+	if err = explainPermissionsQuery(userID).Scan(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	for i, item := range items {
+		if item.Effective {
+			continue
+		}
+
+		for _, link := range item.Chain {
+			if link.State != "active" {
+				items[i].Ignored = fmt.Sprintf("%s %s is %s", strings.ReplaceAll(link.Type, "_", " "), cmp.Or(link.Name, link.UUID), link.State)
+				break
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func RequiresTwoFactor(ctx context.Context, userID int64) (bool, error) {
+	return db.NewSelect().
+		WithRecursive("RoleTree", roleTree()).
+		TableExpr("user_roles AS ur").
+		Join(`JOIN "RoleTree" rt ON rt.role_id = ur.role_id`).
+		Join("JOIN roles r ON r.id = rt.ancestor_id AND r.require_2fa").
+		Where("ur.user_id = ?", userID).
+		Where(effective("ur")).
+		Where(withinWindow("ur")).
+		Exists(ctx)
+}
+
+func userPermissionsQuery(userID int64) *bun.SelectQuery {
+	return db.NewSelect().
+		WithRecursive("RolePath", rolePath()).
+		With("PermissionSources", permissionSourcesUnion()).
+		TableExpr(`"PermissionSources" AS ps`).
+		ColumnExpr("DISTINCT ps.permission").
+		Where("ps.user_id = ?", userID).
+		Where("ps.effective")
+}
+
+func explainPermissionsQuery(userID int64) *bun.SelectQuery {
+	return db.NewSelect().
+		WithRecursive("RolePath", rolePath()).
+		With("PermissionSources", permissionSourcesUnion()).
+		TableExpr(`"PermissionSources" AS ps`).
+		Column("permission", "via", "chain", "effective").
+		Where("ps.user_id = ?", userID).
+		Order("permission ASC", "via ASC")
+}
+
+func roleTree() *bun.SelectQuery {
+	base := db.NewSelect().TableExpr("roles AS r").
+		ColumnExpr("r.id AS role_id, r.id AS ancestor_id").
+		Where(effective("r"))
+
+	parents := db.NewSelect().TableExpr(`"RoleTree" AS rt`).
+		ColumnExpr("rt.role_id, p.id AS ancestor_id").
+		Join("JOIN roles r ON r.id = rt.ancestor_id").
+		Join("JOIN roles p ON p.id = r.parent_role_id AND " + effective("p") + " AND " + inheritableScope("r", "p"))
+
+	return base.Union(parents)
+}
+
+func permissionSourcesUnion() *bun.SelectQuery {
+	roleSources := db.NewSelect().TableExpr("user_roles AS ur").
+		ColumnExpr("ur.user_id, 'role' AS via, p.name AS permission").
+		ColumnExpr(fmt.Sprintf("%s || rt.chain || %s AS chain",
+			permissionChain(assignmentLink("user_role", "ur")),
+			permissionChain(permissionLink("role_permission", "rp", "NULL"), permissionLink("permission", "p", "p.name")))).
+		ColumnExpr(fmt.Sprintf("%s AND %s AND rt.scope = 'global' AND rt.effective AND %s AND %s AS effective",
+			effective("ur"), withinWindow("ur"), effective("rp"), effective("p"))).
+		Join(`JOIN "RolePath" rt ON rt.role_id = ur.role_id`).
+		Join("JOIN role_permissions rp ON rp.role_id = rt.ancestor_id").
+		Join("JOIN permissions p ON p.id = rp.permission_id")
+
+	groupSources := db.NewSelect().TableExpr("user_groups AS ug").
+		ColumnExpr("ug.user_id, 'group' AS via, p.name AS permission").
+		ColumnExpr(permissionChain(
+			assignmentLink("user_group", "ug"),
+			permissionLink("group", "g", "g.name"),
+			permissionLink("group_permission", "gp", "NULL"),
+			permissionLink("permission", "p", "p.name"),
+		) + " AS chain").
+		ColumnExpr(fmt.Sprintf("%s AND %s AND %s AND %s AND %s AS effective",
+			effective("ug"), withinWindow("ug"), effective("g"), effective("gp"), effective("p"))).
+		Join("JOIN groups g ON g.id = ug.group_id").
+		Join("JOIN group_permissions gp ON gp.group_id = g.id").
+		Join("JOIN permissions p ON p.id = gp.permission_id")
+
+	return roleSources.UnionAll(groupSources)
 }
 
-func userPermissionsUnion() *bun.SelectQuery {
-	rolePermsQuery := db.NewSelect().TableExpr("users AS u").
-		ColumnExpr("u.id AS user_id, rp.permission_id").
-		Join("LEFT JOIN user_roles ur ON ur.user_id = u.id AND ur.deleted_at IS NULL AND ur.status = 'O'").
-		Join("LEFT JOIN role_permissions rp ON rp.role_id = ur.role_id AND rp.deleted_at IS NULL AND rp.status = 'O'")
+func rolePath() *bun.SelectQuery {
+	base := db.NewSelect().TableExpr("roles AS r").
+		ColumnExpr("r.id AS role_id, r.id AS ancestor_id, r.scope, ARRAY[r.id] AS visited").
+		ColumnExpr(permissionChain(permissionLink("role", "r", "r.name", "WHEN r.scope <> 'global' THEN 'scoped to ' || r.scope")) + " AS chain").
+		ColumnExpr(effective("r") + " AS effective")
+
+	parents := db.NewSelect().TableExpr(`"RolePath" AS rt`).
+		ColumnExpr("rt.role_id, p.id, rt.scope, rt.visited || p.id").
+		ColumnExpr("rt.chain || " + permissionChain(permissionLink("parent_role", "p", "p.name"))).
+		ColumnExpr("rt.effective AND " + effective("p")).
+		Join("JOIN roles r ON r.id = rt.ancestor_id").
+		Join("JOIN roles p ON p.id = r.parent_role_id AND " + inheritableScope("r", "p")).
+		Where("NOT p.id = ANY(rt.visited)")
+
+	return base.UnionAll(parents)
+}
+
+func effective(alias string) string {
+	return fmt.Sprintf("%[1]s.deleted_at IS NULL AND %[1]s.status = 'O' AND %[1]s.active", alias)
+}
 
-	groupPermsQuery := db.NewSelect().TableExpr("users AS U").
-		ColumnExpr("u.id AS user_id, gp.permission_id").
-		Join("LEFT JOIN user_groups ug ON ug.user_id = u.id AND ug.deleted_at IS NULL AND ug.status = 'O'").
-		Join("LEFT JOIN group_permissions gp ON gp.group_id = ug.group_id AND gp.deleted_at IS NULL AND gp.status = 'O'")
+func inheritableScope(child, parent string) string {
+	return fmt.Sprintf("(%[2]s.scope = 'global' OR %[2]s.scope = %[1]s.scope)", child, parent)
+}
+
+func permissionChain(links ...string) string {
+	return "jsonb_build_array(" + strings.Join(links, ", ") + ")"
+}
+
+func withinWindow(alias string) string {
+	return fmt.Sprintf("(%[1]s.valid_from IS NULL OR %[1]s.valid_from <= NOW()) AND (%[1]s.valid_until IS NULL OR %[1]s.valid_until > NOW())", alias)
+}
+
+func permissionLink(kind, alias, name string, cases ...string) string {
+	return fmt.Sprintf("jsonb_build_object('type', '%s', 'name', %s, 'uuid', %s.uuid, 'state', CASE %s %s ELSE 'active' END)", kind, name, alias, recordState(alias), strings.Join(cases, " "))
+}
+
+func assignmentLink(kind, alias string) string {
+	window := fmt.Sprintf("WHEN %[1]s.valid_from > NOW() THEN 'upcoming' WHEN %[1]s.valid_until <= NOW() THEN 'expired'", alias)
+	return fmt.Sprintf("jsonb_build_object('type', '%s', 'uuid', %s.uuid, 'valid_from', %s.valid_from, 'valid_until', %s.valid_until, 'state', CASE %s %s ELSE 'active' END)", kind, alias, alias, alias, recordState(alias), window)
+}
 
-	return rolePermsQuery.Union(groupPermsQuery)
+func recordState(alias string) string {
+	return fmt.Sprintf("WHEN %[1]s.deleted_at IS NOT NULL THEN 'deleted' WHEN %[1]s.status <> 'O' THEN 'archived' WHEN NOT %[1]s.active THEN 'inactive'", alias)
 }
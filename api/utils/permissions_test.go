@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{"space:read", "space:read", true},
+		{"space:read", "space:edit", false},
+		{"space:read", "user:read", false},
+
+		{"*", "space:delete", true},
+		{"*", "anything", true},
+		{"*:read", "space:read", true},
+		{"*:read", "space:edit", false},
+		{"space:*", "space:delete", true},
+		{"space:*", "user:delete", false},
+		{"*:*", "user:update_status", true},
+
+		{"space:manage", "space:create", true},
+		{"space:manage", "space:edit", true},
+		{"space:manage", "space:read", true},
+		{"space:manage", "space:delete", true},
+		{"space:manage", "space:update_status", true},
+		{"space:manage", "user:read", false},
+		{"*:manage", "user:edit", true},
+		{"space:edit", "space:read", true},
+		{"space:edit", "space:delete", false},
+		{"space:edit", "space:manage", false},
+		{"space:read", "space:manage", false},
+		{"space:create", "space:read", false},
+
+		{"space", "space:read", false},
+		{"space:read", "space", false},
+		{"", "space:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granted+"→"+tt.required, func(t *testing.T) {
+			if got := MatchPermission(tt.granted, tt.required); got != tt.want {
+				t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required []string
+		want     bool
+	}{
+		{"exact", []string{"space:read"}, []string{"space:read"}, true},
+		{"any of required", []string{"space:edit"}, []string{"space:delete", "space:read"}, true},
+		{"none of required", []string{"space:read"}, []string{"space:edit", "space:delete"}, false},
+		{"implied by manage", []string{"user:read", "space:manage"}, []string{"space:edit"}, true},
+		{"module wildcard", []string{"space:*"}, []string{"space:update_status"}, true},
+		{"global wildcard", []string{"*"}, []string{"role:manage"}, true},
+		{"nothing granted", nil, []string{"space:read"}, false},
+		{"nothing required", []string{"*"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.granted, tt.required...); got != tt.want {
+				t.Errorf("HasPermission(%v, %v) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionQueriesAgree(t *testing.T) {
+	InitDB()
+
+	granted := userPermissionsQuery(7).String()
+	explained := explainPermissionsQuery(7).String()
+
+	cte := func(query, main string) string {
+		i := strings.LastIndex(query, main)
+		if i < 0 {
+			t.Fatalf("query has no %q:\n%s", main, query)
+		}
+		return query[:i]
+	}
+
+	if g, e := cte(granted, "SELECT DISTINCT"), cte(explained, `SELECT "permission"`); g != e {
+		t.Errorf("UserPermissions and ExplainPermissions resolve sources differently:\n%s\n%s", g, e)
+	}
+
+	if !strings.Contains(granted, `WHERE (ps.user_id = 7) AND (ps.effective)`) {
+		t.Errorf("UserPermissions should only grant effective sources:\n%s", granted)
+	}
+
+	for _, want := range []string{"rt.scope = 'global'", "ur.valid_until", "ug.valid_until", "g.status = 'O'", "rp.status = 'O'", "gp.status = 'O'", "p.status = 'O'"} {
+		if !strings.Contains(granted, want) {
+			t.Errorf("permission sources are missing %q:\n%s", want, granted)
+		}
+	}
+}
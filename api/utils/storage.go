@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type (
+	Storage interface {
+		Put(ctx context.Context, key string, body []byte, contentType string) error
+		Delete(ctx context.Context, key string) error
+		URL(key string) string
+	}
+
+	LocalStorage struct {
+		Dir     string
+		BaseURL string
+	}
+
+	S3Storage struct {
+		Config StorageConfig
+		once   sync.Once
+		client *minio.Client
+		err    error
+	}
+)
+
+const (
+	defaultMaxUploadSize = 10 << 20
+	defaultMaxPixels     = 40_000_000
+	presignedURLTTL      = time.Hour
+)
+
+var storage Storage
+
+func InitStorage() Storage {
+	if storage == nil {
+		storage = NewStorage(cfg.Storage)
+	}
+
+	return storage
+}
+
+func NewStorage(conf StorageConfig) Storage {
+	switch conf.Driver {
+	case "s3":
+		return &S3Storage{Config: conf}
+	default:
+		return NewLocalStorage(conf.Dir, conf.BaseURL)
+	}
+}
+
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	if dir == "" {
+		dir = "./uploads"
+	}
+
+	if baseURL == "" {
+		baseURL = BaseURL() + "/media"
+	}
+
+	return &LocalStorage{Dir: dir, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func SetStorage(s Storage) {
+	storage = s
+}
+
+func Store() Storage {
+	return storage
+}
+
+func MaxUploadSize() int64 {
+	if cfg.Storage.MaxUploadSize > 0 {
+		return cfg.Storage.MaxUploadSize
+	}
+
+	return defaultMaxUploadSize
+}
+
+func MaxImagePixels() int64 {
+	if cfg.Storage.MaxPixels > 0 {
+		return cfg.Storage.MaxPixels
+	}
+
+	return defaultMaxPixels
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, body, 0o644)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+func (s *LocalStorage) Path(key string) (string, error) {
+	return s.path(key)
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+
+	return filepath.Join(s.Dir, clean), nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	client, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.PutObject(ctx, s.Config.Bucket, key, bytes.NewReader(body), int64(len(body)), minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	client, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	return client.RemoveObject(ctx, s.Config.Bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.Config.BaseURL != "" {
+		return strings.TrimRight(s.Config.BaseURL, "/") + "/" + key
+	}
+
+	client, err := s.connect()
+	if err != nil {
+		return ""
+	}
+
+	u, err := client.PresignedGetObject(context.Background(), s.Config.Bucket, key, presignedURLTTL, url.Values{})
+	if err != nil {
+		return ""
+	}
+
+	return u.String()
+}
+
+func (s *S3Storage) connect() (*minio.Client, error) {
+	s.once.Do(func() {
+		if s.Config.Endpoint == "" || s.Config.Bucket == "" {
+			s.err = fmt.Errorf("s3 endpoint and bucket are not configured")
+			return
+		}
+
+		region := s.Config.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+
+		s.client, s.err = minio.New(s.Config.Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(s.Config.AccessKey, s.Config.SecretKey, ""),
+			Secure: s.Config.UseSSL,
+			Region: region,
+		})
+	})
+
+	return s.client, s.err
+}
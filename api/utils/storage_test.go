@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	s := NewLocalStorage("/srv/uploads", "https://cdn.example.com/media/")
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"spaces/abc/photo.webp", "/srv/uploads/spaces/abc/photo.webp", false},
+		{"/spaces/abc/photo.webp", "/srv/uploads/spaces/abc/photo.webp", false},
+		{"../../etc/passwd", "/srv/uploads/etc/passwd", false},
+		{"spaces/../../secret", "/srv/uploads/secret", false},
+		{"", "", true},
+		{"/", "", true},
+		{"..", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := s.Path(tt.key)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Path(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Path(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if got := s.URL("spaces/abc/photo.webp"); got != "https://cdn.example.com/media/spaces/abc/photo.webp" {
+		t.Errorf("URL() = %q", got)
+	}
+}
+
+func TestLocalStoragePutDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocalStorage(t.TempDir(), "https://cdn.example.com/media")
+	key := "spaces/abc/photo.webp"
+
+	if err := s.Put(ctx, key, []byte("webp"), "image/webp"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.Dir, key))
+	if err != nil || string(data) != "webp" {
+		t.Fatalf("stored file = %q, %v, want %q", data, err, "webp")
+	}
+
+	if err := s.Put(ctx, "../escape.webp", []byte("x"), "image/webp"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.Dir, "escape.webp")); err != nil {
+		t.Errorf("traversal key should be stored inside the storage dir: %v", err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.Dir, key)); !os.IsNotExist(err) {
+		t.Errorf("file still exists after Delete(): %v", err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing file error = %v", err)
+	}
+
+	if err := s.Put(ctx, "", []byte("x"), "image/webp"); err == nil || !strings.Contains(err.Error(), "invalid storage key") {
+		t.Errorf("Put() with an empty key error = %v", err)
+	}
+}
+
+func TestNewStorage(t *testing.T) {
+	if _, ok := NewStorage(StorageConfig{Driver: "s3"}).(*S3Storage); !ok {
+		t.Error("NewStorage(s3) should return an S3Storage")
+	}
+
+	local, ok := NewStorage(StorageConfig{}).(*LocalStorage)
+	if !ok {
+		t.Fatal("NewStorage() should default to LocalStorage")
+	}
+
+	if local.Dir != "./uploads" || !strings.HasSuffix(local.BaseURL, "/media") {
+		t.Errorf("LocalStorage defaults = %q, %q", local.Dir, local.BaseURL)
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed "12345678901234567890" from RFC 6238 appendix B.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 publishes 8-digit codes; a 6-digit code is the same value mod 10^6.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := TOTPCode(rfc6238Secret, tt.unix/totpPeriod)
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+
+			if got != tt.code {
+				t.Errorf("TOTPCode(T=%d) = %s, want %s", tt.unix, got, tt.code)
+			}
+		})
+	}
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode() with an invalid secret should fail")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	step := at.Unix() / totpPeriod
+
+	code := func(step int64) string {
+		c, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, "050471", step, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "050471", step, true},
+		{"spaces in code", rfc6238Secret, "050 471", step, true},
+		{"previous step", rfc6238Secret, code(step - 1), step - 1, true},
+		{"next step", rfc6238Secret, code(step + 1), step + 1, true},
+		{"outside skew", rfc6238Secret, code(step - 2), 0, false},
+		{"wrong code", rfc6238Secret, "000000", 0, false},
+		{"short code", rfc6238Secret, "05047", 0, false},
+		{"invalid secret", "not base32!", "050471", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := ValidateTOTP(tt.secret, tt.code, at)
+
+			if gotOK != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() = %d, %v, want %d, %v", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEncryptSecret(t *testing.T) {
+	previous := cfg
+	t.Cleanup(func() { cfg = previous })
+
+	cfg.Security.TOTPEncryptionKey = "totp-key"
+
+	sealed, err := EncryptSecret(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("EncryptSecret() error = %v", err)
+	}
+
+	if plain, err := DecryptSecret(sealed); err != nil || plain != rfc6238Secret {
+		t.Errorf("DecryptSecret() = %q, %v, want %q", plain, err, rfc6238Secret)
+	}
+
+	cfg.Security.TOTPEncryptionKey = "other-key"
+	if _, err := DecryptSecret(sealed); err == nil {
+		t.Error("DecryptSecret() with the wrong key should fail")
+	}
+
+	cfg.Security.TOTPEncryptionKey = ""
+	if _, err := EncryptSecret(rfc6238Secret); err == nil {
+		t.Error("EncryptSecret() without a key should fail")
+	}
+}